@@ -0,0 +1,55 @@
+package gocom1c
+
+// Backend opens sessions to a 1C infobase.
+// COMBackend is the production implementation, FakeBackend is an in-memory
+// one that needs neither Windows nor 1C.
+type Backend interface {
+	// Open is called on the connection worker goroutine, which is locked
+	// to its OS thread for the lifetime of the session.
+	Open(cfg *Config, logger Logger) (Session, error)
+}
+
+// Session is a single opened 1C session.
+// All methods are called on the worker goroutine that opened the session.
+type Session interface {
+	// ExecuteCommand calls the command processing with a command name and
+	// its params and returns the result as a string.
+	ExecuteCommand(command string, params string) (string, error)
+	// Ping checks that the session is still alive.
+	Ping() error
+	// Connection returns the infobase connection object,
+	// the root of the 1C object model.
+	Connection() Object
+	// Processing returns the command processing object.
+	Processing() Object
+	// ProcessingVersion returns the version of the processing source,
+	// or an empty string when the source has no version.
+	ProcessingVersion() (string, error)
+	// Reload recreates the processing object from its source.
+	Reload() error
+	// Close releases the session resources.
+	Close()
+}
+
+// Object is an object of the 1C object model.
+// Its methods must only be called on the worker goroutine of the session
+// it came from, that is inside COMConnection.Do or COMPool.Do.
+//
+// Arguments and results are nil (Неопределено), bool, string, integers,
+// float64, Decimal, time.Time or Object. A SAFEARRAY of bytes is returned
+// as []byte. Converter maps other Go values.
+type Object interface {
+	// Call calls a method of the object. A result that is an object itself
+	// is returned as Object and must be released by the caller.
+	Call(method string, args ...any) (any, error)
+	// Get reads a property of the object, results are returned as by Call.
+	Get(property string) (any, error)
+	// Put sets a property of the object.
+	Put(property string, value any) error
+	// Each calls fn for every item of a 1C collection, as Для Каждого does.
+	// Object items are released after fn returns.
+	Each(fn func(item any) error) error
+	// Release releases an object returned by Call or Get. Objects returned
+	// by Session are owned by the session, releasing them does nothing.
+	Release()
+}
@@ -0,0 +1,315 @@
+package gocom1c
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// FakeBackend is an in-memory Backend. It needs neither Windows nor 1C
+// and lets the pool and its frontends run on any platform.
+type FakeBackend struct {
+	// Handler serves ExecuteCommand calls. When nil, every command succeeds
+	// and its name and params are echoed back in the WebAPI response format.
+	// Return a *OneCError to simulate an exception raised in 1C.
+	Handler func(command string, params string) (string, error)
+	// BinaryHandler serves ExecuteCommand calls made on Session.Processing,
+	// such as by COMPool.ExecuteBinary. A non-nil result is returned
+	// as a SAFEARRAY of bytes is, a nil one passes the call to Handler.
+	BinaryHandler func(command string, params string) ([]byte, error)
+	// Processing, when set, is returned by Session.Processing in place of
+	// the one serving ExecuteCommand with BinaryHandler and Handler, to
+	// return objects such as ДвоичныеДанные.
+	Processing *FakeObject
+	// OpenErr is returned by Open when set.
+	OpenErr error
+	// PingErr is returned by Ping when set.
+	PingErr error
+	// Delay is added to every command to simulate 1C latency.
+	Delay time.Duration
+	// OpenDelay is added to Open to simulate a slow login.
+	OpenDelay time.Duration
+	// PingDelay is added to Ping to simulate a slow probe.
+	PingDelay time.Duration
+	// ReloadDelay is added to Reload to simulate a slow processing load.
+	ReloadDelay time.Duration
+	// Root is returned by Session.Connection. It is shared by all sessions.
+	Root *FakeObject
+	// Version is returned by Session.ProcessingVersion.
+	Version string
+
+	mu       sync.Mutex
+	down     map[string]bool // connection strings that can not be connected
+	opened   int
+	closed   int
+	reloaded int
+}
+
+type fakeSession struct {
+	backend *FakeBackend
+	tx      int // transaction depth, see fakeConnection
+}
+
+// Open opens a fake session.
+func (b *FakeBackend) Open(cfg *Config, logger Logger) (Session, error) {
+	if b.OpenDelay > 0 {
+		time.Sleep(b.OpenDelay)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.OpenErr != nil {
+		return nil, b.OpenErr
+	}
+	if b.down[cfg.ConnectionString] {
+		return nil, &ConnectError{Phase: PhaseConnect, Err: fmt.Errorf("fake backend: infobase is down")}
+	}
+	b.opened++
+	logger.Debugf("fake backend: session %d opened", b.opened)
+
+	if b.Root == nil {
+		b.Root = &FakeObject{}
+	}
+
+	return &fakeSession{backend: b}, nil
+}
+
+// SetDown makes Open fail to connect to the infobase of the connection
+// string, or connect again, to simulate failover between endpoints.
+func (b *FakeBackend) SetDown(connectionString string, down bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.down == nil {
+		b.down = make(map[string]bool)
+	}
+	b.down[connectionString] = down
+}
+
+// SetOpenErr changes OpenErr while sessions are being opened,
+// to simulate 1C going down and coming back.
+func (b *FakeBackend) SetOpenErr(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.OpenErr = err
+}
+
+// SetPingErr changes PingErr while the pool is running.
+func (b *FakeBackend) SetPingErr(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.PingErr = err
+}
+
+// SetVersion changes Version while the pool is running,
+// to simulate a new version of the processing.
+func (b *FakeBackend) SetVersion(version string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.Version = version
+}
+
+// Reloaded returns the number of processing reloads so far.
+func (b *FakeBackend) Reloaded() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.reloaded
+}
+
+// Opened returns the number of sessions opened so far.
+func (b *FakeBackend) Opened() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.opened
+}
+
+// Closed returns the number of sessions closed so far.
+func (b *FakeBackend) Closed() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+func (s *fakeSession) ExecuteCommand(command string, params string) (string, error) {
+	if s.backend.Delay > 0 {
+		time.Sleep(s.backend.Delay)
+	}
+	if s.backend.Handler != nil {
+		return s.backend.Handler(command, params)
+	}
+	return fakeEcho(command, params)
+}
+
+func (s *fakeSession) Ping() error {
+	if s.backend.PingDelay > 0 {
+		time.Sleep(s.backend.PingDelay)
+	}
+
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+	return s.backend.PingErr
+}
+
+func (s *fakeSession) Connection() Object {
+	return &fakeConnection{FakeObject: s.backend.Root, session: s}
+}
+
+// fakeConnection is the connection object of a session: Root, with
+// the transaction methods of the 1C global context kept per session
+// unless Root serves them itself.
+type fakeConnection struct {
+	*FakeObject
+	session *fakeSession
+}
+
+func (c *fakeConnection) Call(method string, args ...any) (any, error) {
+	c.mu.Lock()
+	_, own := c.Methods[method]
+	c.mu.Unlock()
+	if own {
+		return c.FakeObject.Call(method, args...)
+	}
+
+	switch method {
+	case txBegin:
+		c.session.tx++
+		return nil, nil
+	case txCommit, txRollback:
+		if c.session.tx == 0 {
+			return nil, &OneCError{Description: "Транзакция не активна"}
+		}
+		c.session.tx--
+		return nil, nil
+	case txActive:
+		return c.session.tx > 0, nil
+	}
+	return c.FakeObject.Call(method, args...)
+}
+
+func (s *fakeSession) Processing() Object {
+	if s.backend.Processing != nil {
+		return s.backend.Processing
+	}
+	return &FakeObject{Methods: map[string]func(args ...any) (any, error){
+		"ExecuteCommand": func(args ...any) (any, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("fake processing: ExecuteCommand expects 2 arguments, got %d", len(args))
+			}
+			command, _ := args[0].(string)
+			params, _ := args[1].(string)
+			if s.backend.BinaryHandler != nil {
+				content, err := s.backend.BinaryHandler(command, params)
+				if content != nil || err != nil {
+					return content, err
+				}
+			}
+			return s.ExecuteCommand(command, params)
+		},
+	}}
+}
+
+func (s *fakeSession) ProcessingVersion() (string, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+	return s.backend.Version, nil
+}
+
+func (s *fakeSession) Reload() error {
+	if s.backend.ReloadDelay > 0 {
+		time.Sleep(s.backend.ReloadDelay)
+	}
+
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+	s.backend.reloaded++
+	return nil
+}
+
+func (s *fakeSession) Close() {
+	s.backend.mu.Lock()
+	s.backend.closed++
+	s.backend.mu.Unlock()
+}
+
+// fakeEcho builds a successful WebAPI response holding the command and its params.
+func fakeEcho(command string, params string) (string, error) {
+	var payloadParams any = params
+	if json.Valid([]byte(params)) {
+		payloadParams = json.RawMessage(params)
+	}
+
+	resp, err := json.Marshal(map[string]any{
+		"success": true,
+		"payload": map[string]any{
+			"command": command,
+			"params":  payloadParams,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(resp), nil
+}
+
+// FakeObject is an in-memory Object with properties and methods
+// served by Go functions. Items are the collection items for Each.
+type FakeObject struct {
+	Props   map[string]any
+	Methods map[string]func(args ...any) (any, error)
+	Items   []any
+
+	mu sync.Mutex
+}
+
+// Call calls a method from Methods.
+func (o *FakeObject) Call(method string, args ...any) (any, error) {
+	o.mu.Lock()
+	fn, ok := o.Methods[method]
+	o.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("fake object: method %s not found", method)
+	}
+	return fn(args...)
+}
+
+// Get reads a property from Props.
+func (o *FakeObject) Get(property string) (any, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	val, ok := o.Props[property]
+	if !ok {
+		return nil, fmt.Errorf("fake object: property %s not found", property)
+	}
+	return val, nil
+}
+
+// Put sets a property in Props.
+func (o *FakeObject) Put(property string, value any) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.Props == nil {
+		o.Props = make(map[string]any)
+	}
+	o.Props[property] = value
+	return nil
+}
+
+// Each calls fn for every item of Items.
+func (o *FakeObject) Each(fn func(item any) error) error {
+	o.mu.Lock()
+	items := slices.Clone(o.Items)
+	o.mu.Unlock()
+
+	for _, item := range items {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release does nothing, fake objects are garbage collected.
+func (o *FakeObject) Release() {}
@@ -0,0 +1,89 @@
+package gocom1c
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestFakeBackendExecuteCommand(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1})
+
+	result, err := pool.ExecuteCommand("Ping", `{"a":1}`)
+	if err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	var resp struct {
+		Success bool `json:"success"`
+		Payload struct {
+			Command string         `json:"command"`
+			Params  map[string]int `json:"params"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		t.Fatalf("result %s: %v", result, err)
+	}
+	if !resp.Success || resp.Payload.Command != "Ping" || resp.Payload.Params["a"] != 1 {
+		t.Fatalf("result = %s, want the command echoed", result)
+	}
+	if n := b.Opened(); n != 1 {
+		t.Fatalf("Opened = %d, want 1", n)
+	}
+
+	pool.Close()
+	if n := b.Closed(); n != 1 {
+		t.Fatalf("Closed = %d, want 1", n)
+	}
+}
+
+func TestFakeBackendException(t *testing.T) {
+	b := &FakeBackend{
+		Handler: func(command string, params string) (string, error) {
+			return "", &OneCError{Description: "Документ не найден"}
+		},
+	}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1})
+
+	_, err := pool.ExecuteCommand("Post", "{}")
+	if code := ErrorCode(err); code != CodeException {
+		t.Fatalf("ExecuteCommand = %v, want %s", err, CodeException)
+	}
+	if oneCErr, ok := AsOneCError(err); !ok || oneCErr.Description != "Документ не найден" {
+		t.Fatalf("AsOneCError = %+v, %v", oneCErr, ok)
+	}
+	// an exception in 1C leaves the session usable
+	if stats := pool.Stats(); stats.Broken != 0 || stats.Idle != 1 {
+		t.Fatalf("stats = %+v, want the connection back idle", stats)
+	}
+}
+
+func TestFakeBackendObjectModel(t *testing.T) {
+	b := &FakeBackend{
+		Root: &FakeObject{
+			Props: map[string]any{"ИмяКонфигурации": "Бухгалтерия"},
+			Methods: map[string]func(args ...any) (any, error){
+				"Сумма": func(args ...any) (any, error) {
+					return args[0].(int) + args[1].(int), nil
+				},
+			},
+		},
+	}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1})
+
+	var name, sum any
+	err := pool.Do(context.Background(), func(s Session) error {
+		var err error
+		if name, err = s.Connection().Get("ИмяКонфигурации"); err != nil {
+			return err
+		}
+		sum, err = s.Connection().Call("Сумма", 2, 3)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if name != "Бухгалтерия" || sum != 5 {
+		t.Fatalf("name = %v, sum = %v", name, sum)
+	}
+}
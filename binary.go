@@ -0,0 +1,184 @@
+package gocom1c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// binaryChunkSize is the size of the parts large ДвоичныеДанные
+// are split into with РазделитьДвоичныеДанные
+const binaryChunkSize = 1 << 20
+
+// Binary is a binary result of a command, read like a file. The content
+// is streamed from 1C part by part, the connection stays busy until
+// it is read to the end or Close is called. CommandTimeout limits
+// the calls to 1C, not the time the content waits to be read.
+type Binary struct {
+	// Size is the content length in bytes.
+	Size int64
+	// Text is set instead of the content when the command returned
+	// a string, such as a JSON response naming a file.
+	Text string
+
+	conn   *COMConnection
+	pr     *io.PipeReader
+	pw     *io.PipeWriter
+	header chan struct{} // closed once Size or Text is set
+	once   sync.Once
+	done   chan struct{}
+	err    error
+}
+
+// ExecuteBinary executes a command returning ДвоичныеДанные or a SAFEARRAY
+// of bytes and streams the content back, so it can be served without
+// a disk shared with 1C. ДвоичныеДанные are read through Base64Строка,
+// large ones in parts. The Binary is to be closed.
+func (p *COMPool) ExecuteBinary(ctx context.Context, command string, params string) (*Binary, error) {
+	conn, err := p.GetConnectionContext(ctx)
+	if err != nil {
+		return nil, &CommandError{ConnID: -1, Command: command, Phase: PhaseAcquire, Err: err}
+	}
+
+	b := &Binary{
+		conn:   conn,
+		header: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	b.pr, b.pw = io.Pipe()
+
+	go func() {
+		err := conn.Do(ctx, func(s Session) error {
+			res, err := p.commandCall(s, command, params)
+			if err != nil {
+				return err
+			}
+			err = b.write(s.Connection(), res)
+			if errors.Is(err, io.ErrClosedPipe) {
+				// the reader has been closed, the rest is not needed
+				return nil
+			}
+			return err
+		})
+		p.ReleaseConnection(conn)
+
+		if err != nil {
+			b.err = &CommandError{ConnID: conn.id, Command: command, Phase: PhaseExecute, Err: err}
+		}
+		b.pw.CloseWithError(b.err)
+		close(b.done)
+	}()
+
+	select {
+	case <-b.header:
+		return b, nil
+	case <-b.done:
+		if b.err != nil {
+			return nil, b.err
+		}
+		return b, nil
+	}
+}
+
+// commandCall calls a command on the processing object, which returns
+// its result as is rather than converted to a string
+func (p *COMPool) commandCall(s Session, command string, params string) (any, error) {
+	processing := s.Processing()
+	defer processing.Release()
+
+	if len(p.cfg.CommonModules) > 0 {
+		return processing.Call(command, params)
+	}
+	return processing.Call("ExecuteCommand", command, params)
+}
+
+// write sends a command result into the pipe
+func (b *Binary) write(conn Object, res any) error {
+	switch v := res.(type) {
+	case string:
+		b.Text = v
+		b.ready()
+		return nil
+	case []byte:
+		b.Size = int64(len(v))
+		b.ready()
+		return b.send(v)
+	case Object:
+		defer v.Release()
+		return b.writeBinaryData(conn, v)
+	default:
+		return fmt.Errorf("command returned %T, not binary data", res)
+	}
+}
+
+// writeBinaryData sends ДвоичныеДанные in parts of binaryChunkSize
+func (b *Binary) writeBinaryData(conn Object, data Object) error {
+	conv := NewConverter(conn)
+	if name := conv.typeName(data); name != "base64Binary" {
+		return fmt.Errorf("command returned an object of type %q, not ДвоичныеДанные", name)
+	}
+
+	size, err := data.Call("Размер")
+	if err != nil {
+		return err
+	}
+	n, err := intValue(size)
+	if err != nil {
+		return err
+	}
+	b.Size = int64(n)
+	b.ready()
+
+	if n <= binaryChunkSize {
+		content, err := conv.fromBinaryData(data)
+		if err != nil {
+			return err
+		}
+		return b.send(content.([]byte))
+	}
+
+	parts, err := callObject(conn, "РазделитьДвоичныеДанные", data, binaryChunkSize)
+	if err != nil {
+		return err
+	}
+	defer parts.Release()
+
+	return parts.Each(func(item any) error {
+		part, ok := item.(Object)
+		if !ok {
+			return fmt.Errorf("binary data part is %T, not an object", item)
+		}
+		content, err := conv.fromBinaryData(part)
+		if err != nil {
+			return err
+		}
+		return b.send(content.([]byte))
+	})
+}
+
+// send writes a part of the content, it blocks until the part is read
+func (b *Binary) send(content []byte) error {
+	return b.conn.pauseTimeout(func() error {
+		_, err := b.pw.Write(content)
+		return err
+	})
+}
+
+func (b *Binary) ready() {
+	b.once.Do(func() { close(b.header) })
+}
+
+// Read reads the content. It returns the error the command failed with
+// when the read from 1C breaks off.
+func (b *Binary) Read(p []byte) (int, error) {
+	return b.pr.Read(p)
+}
+
+// Close stops the read and frees the connection.
+func (b *Binary) Close() error {
+	b.pr.Close()
+	<-b.done
+	return b.err
+}
@@ -0,0 +1,175 @@
+package gocom1c
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// binaryData is ДвоичныеДанные of content for fakeConverterConn
+func binaryData(content []byte) *FakeObject {
+	data := typed("base64Binary", map[string]any{"base64": base64.StdEncoding.EncodeToString(content)})
+	data.Methods = map[string]func(args ...any) (any, error){
+		"Размер": func(args ...any) (any, error) { return len(content), nil },
+	}
+	return data
+}
+
+// fakeBinaryBackend returns a backend whose commands return ДвоичныеДанные
+// of size bytes, split into parts of binaryChunkSize. Reading the part
+// broken fails with err.
+func fakeBinaryBackend(size int, broken int, err error) (*FakeBackend, []byte) {
+	content := bytes.Repeat([]byte("0123456789"), size/10)
+	root := fakeConverterConn()
+	root.Methods["РазделитьДвоичныеДанные"] = func(args ...any) (any, error) {
+		var parts []any
+		for i := 0; i*binaryChunkSize < len(content); i++ {
+			part := binaryData(content[i*binaryChunkSize : min(len(content), (i+1)*binaryChunkSize)])
+			if i == broken {
+				part.Props["base64"] = err
+			}
+			parts = append(parts, part)
+		}
+		return &FakeObject{Items: parts}, nil
+	}
+	base64String := root.Methods["Base64Строка"]
+	root.Methods["Base64Строка"] = func(args ...any) (any, error) {
+		res, _ := base64String(args...)
+		if err, ok := res.(error); ok {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	return &FakeBackend{
+		Root: root,
+		Processing: &FakeObject{Methods: map[string]func(args ...any) (any, error){
+			"ExecuteCommand": func(args ...any) (any, error) { return binaryData(content), nil },
+		}},
+	}, content
+}
+
+func TestExecuteBinaryParts(t *testing.T) {
+	b, content := fakeBinaryBackend(2*binaryChunkSize+100, -1, nil)
+	pool := newTestPool(t, b, Config{MinPoolSize: 1})
+
+	bin, err := pool.ExecuteBinary(context.Background(), "Отчет", "{}")
+	if err != nil {
+		t.Fatalf("ExecuteBinary: %v", err)
+	}
+	if bin.Size != int64(len(content)) {
+		t.Fatalf("Size = %d, want %d", bin.Size, len(content))
+	}
+	got, err := io.ReadAll(bin)
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("ReadAll = %d bytes, %v, want %d bytes", len(got), err, len(content))
+	}
+	if err := bin.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if stats := pool.Stats(); stats.Idle != 1 {
+		t.Fatalf("stats = %+v, want the connection back idle", stats)
+	}
+}
+
+func TestExecuteBinaryErrorMidStream(t *testing.T) {
+	b, _ := fakeBinaryBackend(3*binaryChunkSize, 1, &OneCError{Description: "Недостаточно памяти"})
+	pool := newTestPool(t, b, Config{MinPoolSize: 1})
+
+	bin, err := pool.ExecuteBinary(context.Background(), "Отчет", "{}")
+	if err != nil {
+		t.Fatalf("ExecuteBinary: %v", err)
+	}
+	got, err := io.ReadAll(bin)
+	if len(got) != binaryChunkSize {
+		t.Fatalf("read %d bytes before the failure, want %d", len(got), binaryChunkSize)
+	}
+	if oneCErr, ok := AsOneCError(err); !ok || oneCErr.Description != "Недостаточно памяти" {
+		t.Fatalf("ReadAll = %v, want the exception of the broken part", err)
+	}
+	if closeErr := bin.Close(); closeErr != err {
+		t.Fatalf("Close = %v, want %v", closeErr, err)
+	}
+	if stats := pool.Stats(); stats.Idle != 1 || stats.Broken != 0 {
+		t.Fatalf("stats = %+v, want the connection back idle", stats)
+	}
+}
+
+func TestExecuteBinaryCloseEarly(t *testing.T) {
+	content := bytes.Repeat([]byte{1}, 4*binaryChunkSize)
+	b := &FakeBackend{BinaryHandler: func(command string, params string) ([]byte, error) {
+		return content, nil
+	}}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, MaxPoolSize: 1})
+
+	bin, err := pool.ExecuteBinary(context.Background(), "Отчет", "{}")
+	if err != nil {
+		t.Fatalf("ExecuteBinary: %v", err)
+	}
+	if n, err := bin.Read(make([]byte, 100)); n != 100 || err != nil {
+		t.Fatalf("Read = %d, %v", n, err)
+	}
+	if stats := pool.Stats(); stats.Idle != 0 {
+		t.Fatalf("stats = %+v, want the connection busy while read", stats)
+	}
+
+	// the rest is dropped and the connection is freed at once
+	if err := bin.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if stats := pool.Stats(); stats.Idle != 1 || stats.Broken != 0 || stats.Quarantined != 0 {
+		t.Fatalf("stats = %+v, want the connection back idle", stats)
+	}
+	if _, err := bin.Read(make([]byte, 1)); err == nil {
+		t.Fatal("Read after Close succeeded")
+	}
+	if _, err := pool.ExecuteCommand("Ping", "{}"); err != nil {
+		t.Fatalf("ExecuteCommand after Close: %v", err)
+	}
+}
+
+func TestExecuteBinaryCanceled(t *testing.T) {
+	content := bytes.Repeat([]byte{1}, 4*binaryChunkSize)
+	b := &FakeBackend{BinaryHandler: func(command string, params string) ([]byte, error) {
+		return content, nil
+	}}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, MaxPoolSize: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bin, err := pool.ExecuteBinary(ctx, "Отчет", "{}")
+	if err != nil {
+		t.Fatalf("ExecuteBinary: %v", err)
+	}
+	if _, err := io.ReadFull(bin, make([]byte, 100)); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	cancel()
+	readErr := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, bin)
+		readErr <- err
+	}()
+	select {
+	case err := <-readErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("read = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the read goes on after the cancel")
+	}
+	if err := bin.Close(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Close = %v, want context.Canceled", err)
+	}
+
+	// the pool serves again once the abandoned call is over
+	res, err := pool.ExecuteCommand("Ping", "{}")
+	if err != nil || !bytes.Contains(res, []byte("Ping")) {
+		t.Fatalf("ExecuteCommand after the cancel = %s, %v", res, err)
+	}
+}
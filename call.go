@@ -0,0 +1,105 @@
+package gocom1c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Call calls a method of the object found by path from the infobase
+// connection, e.g. Call(ctx, "Справочники.Номенклатура", "НайтиПоКоду", "001").
+// An empty path calls a method of the connection itself.
+// Arguments and the result are converted by Converter.
+func (p *COMPool) Call(ctx context.Context, path string, method string, args ...any) (any, error) {
+	name := method
+	if path != "" {
+		name = path + "." + method
+	}
+
+	return p.call(ctx, name, method, args, func(s Session) (Object, error) {
+		return resolvePath(s.Connection(), path)
+	})
+}
+
+// ExecuteMethod calls an export method of the command processing with
+// any number of arguments, converted by Converter as is the result.
+// Idempotent methods are retried once when the connection breaks.
+func (p *COMPool) ExecuteMethod(ctx context.Context, method string, args ...any) (any, error) {
+	processing := func(s Session) (Object, error) {
+		return s.Processing(), nil
+	}
+
+	res, err := p.call(ctx, method, method, args, processing)
+	if err != nil && errors.Is(err, ErrConnBroken) && p.cfg.IsIdempotent(method) {
+		p.logger.Warnf("Retrying idempotent method %s after broken connection: %v", method, err)
+		res, err = p.call(ctx, method, method, args, processing)
+	}
+	return res, err
+}
+
+// call calls method of the object returned by target on a pooled
+// connection. name identifies the call in CommandError.
+func (p *COMPool) call(ctx context.Context, name string, method string, args []any,
+	target func(s Session) (Object, error)) (any, error) {
+	conn, err := p.GetConnectionContext(ctx)
+	if err != nil {
+		return nil, &CommandError{ConnID: -1, Command: name, Phase: PhaseAcquire, Err: err}
+	}
+	defer p.ReleaseConnection(conn)
+
+	var res any
+	err = conn.Do(ctx, func(s Session) error {
+		obj, err := target(s)
+		if err != nil {
+			return err
+		}
+		defer obj.Release()
+
+		res, err = callConverted(s.Connection(), obj, method, args)
+		return err
+	})
+	if err != nil {
+		return nil, &CommandError{ConnID: conn.id, Command: name, Phase: PhaseExecute, Err: err}
+	}
+	return res, nil
+}
+
+// resolvePath walks the dotted property path from obj. The returned object
+// is to be released by the caller, intermediate objects are released here.
+func resolvePath(obj Object, path string) (Object, error) {
+	if path == "" {
+		return obj, nil
+	}
+
+	for _, name := range strings.Split(path, ".") {
+		next, err := getObject(obj, name)
+		obj.Release()
+		if err != nil {
+			return nil, err
+		}
+		obj = next
+	}
+	return obj, nil
+}
+
+// callConverted calls a method of obj with arguments converted to 1C
+// values and converts the result back
+func callConverted(conn Object, obj Object, method string, args []any) (any, error) {
+	conv := NewConverter(conn)
+	defer conv.Release()
+
+	oneCArgs := make([]any, len(args))
+	for i, arg := range args {
+		var err error
+		if oneCArgs[i], err = conv.ToOneC(arg); err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+	}
+
+	res, err := obj.Call(method, oneCArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return conv.FromOneC(res)
+}
@@ -0,0 +1,550 @@
+package gocom1c
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+	"github.com/go-ole/go-ole/oleutil"
+)
+
+// HRESULT codes meaning the COM server or the RPC channel to it is gone
+const (
+	hrRPCServerDied          = 0x80010007
+	hrRPCServerDiedDNE       = 0x80010012
+	hrRPCDisconnected        = 0x80010108
+	hrCOObjNotConnected      = 0x800401FD
+	hrRPCServerUnavailable   = 0x800706BA
+	hrRPCCallFailed          = 0x800706BE
+	hrRPCCallFailedDNE       = 0x800706BF
+	hrRPCConnectionAborted   = 0x800704D4
+	hrRPCConnectionRefused   = 0x800704C9
+	hrRPCEndpointUnavailable = 0x800706D9
+)
+
+// brokenSessionTexts are fragments of 1C exception texts raised when
+// the session with the 1C server is lost
+var brokenSessionTexts = []string{
+	"соединение с сервером 1с:предприятия разорвано",
+	"ошибка соединения с сервером",
+	"сеанс работы завершен администратором",
+	"сеанс отсутствует или удален",
+	"server connection lost",
+	"session has been terminated",
+}
+
+// COMBackend opens sessions through the 1C COM connector (V83.COMConnector).
+type COMBackend struct{}
+
+// comSession is a COM session with the external processing loaded,
+// or with the common modules serving commands.
+type comSession struct {
+	cfg               *Config
+	logger            Logger
+	pingCommand       string
+	unknown           *ole.IUnknown
+	dispatch          *ole.IDispatch
+	v8                *ole.VARIANT
+	commandExecParent *ole.VARIANT
+	commandExec       *ole.VARIANT
+	moduleNames       []string
+	modules           map[string]*ole.VARIANT
+	processingFile    string // temp file the processing was created from
+}
+
+// Open initializes COM on the calling thread, connects to 1C and
+// creates the command processing object.
+func (COMBackend) Open(cfg *Config, logger Logger) (Session, error) {
+	// Initialize COM
+	if err := ole.CoInitialize(0); err != nil {
+		return nil, &ConnectError{Phase: PhaseInit, Err: fmt.Errorf("CoInitialize failed: %w", err)}
+	}
+
+	s := &comSession{cfg: cfg, logger: logger, pingCommand: cfg.PingCommand}
+	if err := s.createConnector(cfg, logger); err != nil {
+		s.Close()
+		return nil, &ConnectError{Phase: PhaseInit, Err: err}
+	}
+	if err := s.connect(cfg, logger); err != nil {
+		s.Close()
+		return nil, &ConnectError{Phase: PhaseConnect, Err: err}
+	}
+	load := s.loadProcessing
+	if len(cfg.CommonModules) > 0 {
+		load = s.loadModules
+	}
+	if err := load(cfg, logger); err != nil {
+		s.Close()
+		return nil, &ConnectError{Phase: PhaseProcessing, Err: err}
+	}
+
+	return s, nil
+}
+
+// createConnector creates the COM connector object
+func (s *comSession) createConnector(cfg *Config, logger Logger) error {
+	logger.Debugf("initializing COM: %s", cfg.COMObjectID)
+
+	var err error
+	s.unknown, err = oleutil.CreateObject(cfg.COMObjectID)
+	if err != nil {
+		return fmt.Errorf("create COMConnector failed: %w", err)
+	}
+
+	s.dispatch, err = s.unknown.QueryInterface(ole.IID_IDispatch)
+	if err != nil {
+		return fmt.Errorf("QueryInterface failed: %w", err)
+	}
+	return nil
+}
+
+// connect connects to the infobase
+func (s *comSession) connect(cfg *Config, logger Logger) error {
+	cs, err := ParseConnectionString(cfg.ConnectionString)
+	if err != nil {
+		return err
+	}
+	if err := cs.Validate(); err != nil {
+		return err
+	}
+	logger.Debugf("trying to connect with: %s", cs.Redacted())
+
+	s.v8, err = oleutil.CallMethod(s.dispatch, "Connect", cfg.ConnectionString)
+	if err != nil {
+		return fmt.Errorf("1C Connect to %s failed: %w", cs.Redacted(), classifyCOMError(err))
+	}
+	return nil
+}
+
+// loadProcessing creates the command processing object
+// from the configured source
+func (s *comSession) loadProcessing(cfg *Config, logger Logger) error {
+	switch cfg.ProcessingSource {
+	case SourceFile:
+		path, err := filepath.Abs(cfg.ProcessingFile)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("processing file: %w", err)
+		}
+		return s.createProcessing(path, logger)
+
+	case SourceEmbedded:
+		path, err := s.embeddedProcessingFile(cfg.ProcessingData)
+		if err != nil {
+			return err
+		}
+		// the file is kept for other sessions until this one is released
+		s.processingFile = path
+		return s.createProcessing(path, logger)
+
+	case SourceExtension:
+		return s.createFromManager(cfg.CommandExec, logger)
+
+	case SourceCatalog:
+		return s.loadFromCatalog(cfg, logger)
+
+	default:
+		return fmt.Errorf("unknown processing source %q", cfg.ProcessingSource)
+	}
+}
+
+// loadFromCatalog finds the command processing in
+// ДополнительныеОтчетыИОбработки and creates its object
+func (s *comSession) loadFromCatalog(cfg *Config, logger Logger) error {
+	extForm, err := s.findCatalogItem(cfg.CommandExec)
+	if err != nil {
+		return err
+	}
+	defer extForm.Clear()
+
+	// Get ХранилищеОбработки from extForm
+	obrStore, err := oleutil.GetProperty(extForm.ToIDispatch(), "ХранилищеОбработки")
+	if err != nil {
+		return fmt.Errorf("object property 'ХранилищеОбработки' not found: %w", err)
+	}
+
+	// Get data from storage
+	data, err := oleutil.CallMethod(obrStore.ToIDispatch(), "Получить")
+	obrStore.Clear() // Clear obrStore now that we have data
+	if err != nil {
+		return fmt.Errorf("method 'Получить()' not found: %w", err)
+	}
+	defer data.Clear()
+
+	// Write to a temp file, which is not needed once Создать() is done
+	tempFileName, err := s.cfg.tempFiles.Create("", ".epf", func(path string) error {
+		if _, err := oleutil.CallMethod(data.ToIDispatch(), "Записать", path); err != nil {
+			return fmt.Errorf("method 'Записать()' not found: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	defer s.cfg.tempFiles.Release(tempFileName)
+
+	return s.createProcessing(tempFileName, logger)
+}
+
+// findCatalogItem finds the processing item in ДополнительныеОтчетыИОбработки
+// by its name. The returned variant is to be cleared by the caller.
+func (s *comSession) findCatalogItem(name string) (*ole.VARIANT, error) {
+	// Get справочники
+	spr, err := oleutil.GetProperty(s.v8.ToIDispatch(), "Справочники")
+	if err != nil {
+		return nil, fmt.Errorf("object property 'Справочники' not found: %w", err)
+	}
+
+	// Get ДополнительныеОтчетыИОбработки
+	sprOtch, err := oleutil.GetProperty(spr.ToIDispatch(), "ДополнительныеОтчетыИОбработки")
+	spr.Clear() // Clear spr now that we have sprOtch
+	if err != nil {
+		return nil, fmt.Errorf("object property 'ДополнительныеОтчетыИОбработки' not found: %w", err)
+	}
+
+	// Find обработка by name
+	extForm, err := oleutil.CallMethod(sprOtch.ToIDispatch(), "НайтиПоНаименованию", name, true)
+	sprOtch.Clear() // Clear sprOtch now that we have extForm
+	if err != nil {
+		return nil, fmt.Errorf("method 'НайтиПоНаименованию()' not found: %w", err)
+	}
+
+	// Check if empty
+	isEmpty, err := oleutil.CallMethod(extForm.ToIDispatch(), "Пустая")
+	if err != nil {
+		extForm.Clear()
+		return nil, fmt.Errorf("method 'Пустая()' not found: %w", err)
+	}
+
+	isEmptyRes, ok := isEmpty.Value().(bool)
+	isEmpty.Clear() // Clear isEmpty immediately after getting value
+	if !ok {
+		extForm.Clear()
+		return nil, fmt.Errorf("invalid result type from Пустая()")
+	}
+	if isEmptyRes {
+		extForm.Clear()
+		return nil, fmt.Errorf("не найдена внешняя обработка \"%s\"", name)
+	}
+	return extForm, nil
+}
+
+// createProcessing creates the external processing from a file
+func (s *comSession) createProcessing(path any, logger Logger) error {
+	// Get ВнешниеОбработки, keep it alive for the connection lifetime
+	var err error
+	s.commandExecParent, err = oleutil.GetProperty(s.v8.ToIDispatch(), "ВнешниеОбработки")
+	if err != nil {
+		return fmt.Errorf("object property 'ВнешниеОбработки' not found: %w", err)
+	}
+
+	logger.Debugf("Creating обработка from file: %v", path)
+
+	// Call Создать on внешниеОбработки
+	s.commandExec, err = oleutil.CallMethod(s.commandExecParent.ToIDispatch(), "Создать", path, false)
+	if err != nil {
+		return fmt.Errorf("method 'Создать()' not found: %w", err)
+	}
+
+	return nil
+}
+
+// createFromManager creates Обработки.<name>, a processing of
+// the configuration or of an attached extension
+func (s *comSession) createFromManager(name string, logger Logger) error {
+	managers, err := oleutil.GetProperty(s.v8.ToIDispatch(), "Обработки")
+	if err != nil {
+		return fmt.Errorf("object property 'Обработки' not found: %w", err)
+	}
+	defer managers.Clear()
+
+	// keep the manager alive for the connection lifetime
+	s.commandExecParent, err = oleutil.GetProperty(managers.ToIDispatch(), name)
+	if err != nil {
+		return fmt.Errorf("processing '%s' not found: %w", name, err)
+	}
+
+	logger.Debugf("Creating обработка %s", name)
+
+	s.commandExec, err = oleutil.CallMethod(s.commandExecParent.ToIDispatch(), "Создать")
+	if err != nil {
+		return fmt.Errorf("method 'Создать()' not found: %w", err)
+	}
+
+	return nil
+}
+
+// embeddedProcessingFile writes the processing content to a temporary
+// file shared by the sessions with the same content and returns its path.
+// The COM connector runs 1C in this process, so 1C reads the file from
+// the local disk.
+func (s *comSession) embeddedProcessingFile(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("processing data is empty")
+	}
+	sum := sha256.Sum256(data)
+
+	return s.cfg.tempFiles.Create(hex.EncodeToString(sum[:8]), ".epf", func(path string) error {
+		return os.WriteFile(path, data, 0o600)
+	})
+}
+
+// loadModules gets the common modules from the connection
+func (s *comSession) loadModules(cfg *Config, logger Logger) error {
+	s.moduleNames = cfg.CommonModules
+	s.modules = make(map[string]*ole.VARIANT, len(cfg.CommonModules))
+	for _, name := range cfg.CommonModules {
+		logger.Debugf("getting common module: %s", name)
+
+		module, err := oleutil.GetProperty(s.v8.ToIDispatch(), name)
+		if err != nil {
+			return fmt.Errorf("common module '%s' not found: %w", name, err)
+		}
+		s.modules[name] = module
+	}
+	return nil
+}
+
+// module returns the common module and the method a command names
+func (s *comSession) module(command string) (*ole.VARIANT, string, error) {
+	name, method, ok := strings.Cut(command, ".")
+	if !ok {
+		name, method = s.moduleNames[0], command
+	}
+	module, found := s.modules[name]
+	if !found {
+		return nil, "", fmt.Errorf("common module '%s' is not configured", name)
+	}
+	return module, method, nil
+}
+
+// ExecuteCommand calls ExecuteCommand(command, params) of the processing,
+// or Module.Method(params) in common modules mode.
+func (s *comSession) ExecuteCommand(command string, params string) (string, error) {
+	target, method, args := s.commandExec, "ExecuteCommand", []any{command, params}
+	if s.modules != nil {
+		var err error
+		if target, method, err = s.module(command); err != nil {
+			return "", err
+		}
+		args = []any{params}
+	}
+
+	res, err := oleutil.CallMethod(target.ToIDispatch(), method, args...)
+	if err != nil {
+		return "", classifyCOMError(err)
+	}
+	defer res.Clear()
+
+	// Convert result to string
+	val := res.Value()
+	switch v := val.(type) {
+	case string:
+		return v, nil
+	case fmt.Stringer:
+		return v.String(), nil
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
+}
+
+// Ping calls the configured ping command of the processing, or reads
+// the Метаданные property of the connection when none is configured.
+func (s *comSession) Ping() error {
+	if s.pingCommand != "" {
+		_, err := s.ExecuteCommand(s.pingCommand, "null")
+		return err
+	}
+
+	md, err := oleutil.GetProperty(s.v8.ToIDispatch(), "Метаданные")
+	if err != nil {
+		return classifyCOMError(err)
+	}
+	md.Clear()
+	return nil
+}
+
+// ProcessingVersion returns ВерсияДанных of the catalog item or
+// the modification time of the processing file. Other sources have
+// no version and return an empty string.
+func (s *comSession) ProcessingVersion() (string, error) {
+	if s.modules != nil {
+		return "", nil
+	}
+
+	switch s.cfg.ProcessingSource {
+	case SourceCatalog:
+		extForm, err := s.findCatalogItem(s.cfg.CommandExec)
+		if err != nil {
+			return "", classifyCOMError(err)
+		}
+		defer extForm.Clear()
+
+		version, err := oleutil.GetProperty(extForm.ToIDispatch(), "ВерсияДанных")
+		if err != nil {
+			return "", classifyCOMError(err)
+		}
+		defer version.Clear()
+		return fmt.Sprintf("%v", version.Value()), nil
+
+	case SourceFile:
+		info, err := os.Stat(s.cfg.ProcessingFile)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), nil
+
+	default:
+		return "", nil
+	}
+}
+
+// Reload recreates the processing object from its source.
+func (s *comSession) Reload() error {
+	if s.modules != nil {
+		return nil
+	}
+
+	s.releaseProcessing()
+	return s.loadProcessing(s.cfg, s.logger)
+}
+
+// releaseProcessing releases the processing object, its parent
+// and the file it was created from
+func (s *comSession) releaseProcessing() {
+	if s.commandExec != nil {
+		s.commandExec.Clear()
+		s.commandExec = nil
+	}
+	if s.commandExecParent != nil {
+		s.commandExecParent.Clear()
+		s.commandExecParent = nil
+	}
+	if s.processingFile != "" {
+		s.cfg.tempFiles.Release(s.processingFile)
+		s.processingFile = ""
+	}
+}
+
+// Close releases COM objects in reverse order and uninitializes COM.
+func (s *comSession) Close() {
+	s.releaseProcessing()
+	for name, module := range s.modules {
+		module.Clear()
+		delete(s.modules, name)
+	}
+	if s.v8 != nil {
+		s.v8.Clear()
+		s.v8 = nil
+	}
+	if s.dispatch != nil {
+		s.dispatch.Release()
+		s.dispatch = nil
+	}
+	if s.unknown != nil {
+		s.unknown.Release()
+		s.unknown = nil
+	}
+	ole.CoUninitialize()
+}
+
+// classifyCOMError converts a 1C exception into OneCError and wraps
+// errors meaning a lost 1C session with ErrConnBroken
+func classifyCOMError(err error) error {
+	var oleErr *ole.OleError
+	if !errors.As(err, &oleErr) {
+		return err
+	}
+	if oneCErr := newOneCError(oleErr); oneCErr != nil {
+		err = oneCErr
+	}
+
+	switch uint32(oleErr.Code()) {
+	case hrRPCServerDied, hrRPCServerDiedDNE, hrRPCDisconnected, hrCOObjNotConnected,
+		hrRPCServerUnavailable, hrRPCCallFailed, hrRPCCallFailedDNE,
+		hrRPCConnectionAborted, hrRPCConnectionRefused, hrRPCEndpointUnavailable:
+		return fmt.Errorf("%w: %w", ErrConnBroken, err)
+	}
+
+	descr := strings.ToLower(oleErr.Description())
+	for _, text := range brokenSessionTexts {
+		if strings.Contains(descr, text) {
+			return fmt.Errorf("%w: %w", ErrConnBroken, err)
+		}
+	}
+
+	return err
+}
+
+// newOneCError extracts the 1C exception from the EXCEPINFO of a failed
+// call, or returns nil when the error carries none
+func newOneCError(oleErr *ole.OleError) *OneCError {
+	excep, ok := oleErr.SubError().(ole.EXCEPINFO)
+	if !ok {
+		return nil
+	}
+
+	module, line, text := parseOneCDescription(oleErr.Description())
+	code := excep.SCODE()
+	if excep.WCode() != 0 {
+		code = uint32(excep.WCode())
+	}
+
+	return &OneCError{
+		Description: text,
+		Source:      excepSource(excep),
+		Module:      module,
+		Line:        line,
+		HRESULT:     uint32(oleErr.Code()),
+		Code:        code,
+		err:         oleErr,
+	}
+}
+
+// excepInfo mirrors ole.EXCEPINFO of go-ole v1.3: the native EXCEPINFO
+// followed by its strings, which go-ole renders before it frees the BSTRs
+// after a failed call. go-ole keeps all of them unexported.
+type excepInfo struct {
+	wCode             uint16
+	wReserved         uint16
+	bstrSource        *uint16
+	bstrDescription   *uint16
+	bstrHelpFile      *uint16
+	dwHelpContext     uint32
+	pvReserved        uintptr
+	pfnDeferredFillIn uintptr
+	scode             uint32
+
+	rendered    bool
+	source      string
+	description string
+	helpFile    string
+}
+
+// excepSource returns the exception source of EXCEPINFO, read from its
+// fields. When ole.EXCEPINFO no longer matches excepInfo, such as after
+// a go-ole upgrade, the source is left empty.
+func excepSource(excep ole.EXCEPINFO) string {
+	if unsafe.Sizeof(excep) != unsafe.Sizeof(excepInfo{}) {
+		return ""
+	}
+	info := (*excepInfo)(unsafe.Pointer(&excep))
+
+	// the BSTRs are freed by now, only the rendered strings are left.
+	// The description go-ole returns proves the layout.
+	if !info.rendered || info.source == "<nil>" {
+		return ""
+	}
+	if info.description != "<nil>" && strings.TrimSpace(info.description) != excep.Error() {
+		return ""
+	}
+	return info.source
+}
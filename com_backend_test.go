@@ -0,0 +1,83 @@
+package gocom1c
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+)
+
+// newExcepInfo returns an EXCEPINFO as go-ole leaves it after a failed call
+func newExcepInfo(info excepInfo) ole.EXCEPINFO {
+	info.rendered = true
+	return *(*ole.EXCEPINFO)(unsafe.Pointer(&info))
+}
+
+func TestExcepSource(t *testing.T) {
+	tests := []struct {
+		name string
+		info excepInfo
+		want string
+	}{
+		{
+			name: "source",
+			info: excepInfo{source: "V83.COMConnector.1", description: "Деление на 0", helpFile: "<nil>"},
+			want: "V83.COMConnector.1",
+		},
+		{
+			name: "no source",
+			info: excepInfo{source: "<nil>", description: "Деление на 0", helpFile: "<nil>"},
+			want: "",
+		},
+		{
+			name: "no description",
+			info: excepInfo{source: "1C:Enterprise", description: "<nil>", helpFile: "<nil>", scode: 0x80004005},
+			want: "1C:Enterprise",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := excepSource(newExcepInfo(tt.info)); got != tt.want {
+				t.Errorf("excepSource = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// an EXCEPINFO go-ole has not rendered is not read
+	if got := excepSource(ole.EXCEPINFO{}); got != "" {
+		t.Errorf("excepSource of an empty EXCEPINFO = %q", got)
+	}
+}
+
+func TestNewOneCError(t *testing.T) {
+	descr := "{ВнешняяОбработка.WebAPI.МодульОбъекта(42)}: Не найден документ"
+	excep := newExcepInfo(excepInfo{
+		wCode:       0x3E9,
+		source:      "V83.COMConnector.1",
+		description: descr,
+		helpFile:    "<nil>",
+	})
+	oleErr := ole.NewErrorWithSubError(0x80020009, descr, excep)
+
+	got := newOneCError(oleErr)
+	want := OneCError{
+		Description: "Не найден документ",
+		Source:      "V83.COMConnector.1",
+		Module:      "ВнешняяОбработка.WebAPI.МодульОбъекта",
+		Line:        42,
+		HRESULT:     0x80020009,
+		Code:        0x3E9,
+	}
+	if got == nil {
+		t.Fatal("newOneCError = nil")
+	}
+	if got.Description != want.Description || got.Source != want.Source || got.Module != want.Module ||
+		got.Line != want.Line || got.HRESULT != want.HRESULT || got.Code != want.Code {
+		t.Fatalf("newOneCError = %+v, want %+v", *got, want)
+	}
+
+	// a COM error without EXCEPINFO is not a 1C exception
+	if got := newOneCError(ole.NewError(0x80004005)); got != nil {
+		t.Fatalf("newOneCError without EXCEPINFO = %+v", got)
+	}
+}
@@ -0,0 +1,162 @@
+package gocom1c
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-ole/go-ole"
+	"github.com/go-ole/go-ole/oleutil"
+)
+
+// comObject is a 1C object held by a COM VARIANT
+type comObject struct {
+	v     *ole.VARIANT
+	owned bool // the variant is cleared on Release
+}
+
+// Connection returns the infobase connection object.
+func (s *comSession) Connection() Object {
+	return &comObject{v: s.v8}
+}
+
+// Processing returns the command processing object. In common modules
+// mode it is an object whose methods are named Module.Method.
+func (s *comSession) Processing() Object {
+	if s.modules != nil {
+		return &moduleObject{s: s}
+	}
+	return &comObject{v: s.commandExec}
+}
+
+// moduleObject routes Module.Method calls to the common modules
+type moduleObject struct {
+	s *comSession
+}
+
+func (o *moduleObject) Call(method string, args ...any) (any, error) {
+	module, method, err := o.s.module(method)
+	if err != nil {
+		return nil, err
+	}
+	return (&comObject{v: module}).Call(method, args...)
+}
+
+func (o *moduleObject) Get(property string) (any, error) {
+	return nil, fmt.Errorf("common modules have no property %s", property)
+}
+
+func (o *moduleObject) Put(property string, value any) error {
+	return fmt.Errorf("common modules have no property %s", property)
+}
+
+func (o *moduleObject) Each(fn func(item any) error) error {
+	return fmt.Errorf("common modules are not a collection")
+}
+
+func (o *moduleObject) Release() {}
+
+// Call calls a method of the object.
+func (o *comObject) Call(method string, args ...any) (res any, err error) {
+	defer recoverInvoke(method, &err)
+
+	v, err := oleutil.CallMethod(o.v.ToIDispatch(), method, comArgs(args)...)
+	if err != nil {
+		return nil, classifyCOMError(err)
+	}
+	return comResult(v), nil
+}
+
+// Get reads a property of the object.
+func (o *comObject) Get(property string) (res any, err error) {
+	defer recoverInvoke(property, &err)
+
+	v, err := oleutil.GetProperty(o.v.ToIDispatch(), property)
+	if err != nil {
+		return nil, classifyCOMError(err)
+	}
+	return comResult(v), nil
+}
+
+// Put sets a property of the object.
+func (o *comObject) Put(property string, value any) (err error) {
+	defer recoverInvoke(property, &err)
+
+	v, err := oleutil.PutProperty(o.v.ToIDispatch(), property, comArgs([]any{value})...)
+	if err != nil {
+		return classifyCOMError(err)
+	}
+	v.Clear()
+	return nil
+}
+
+// Each enumerates the object with _NewEnum.
+func (o *comObject) Each(fn func(item any) error) (err error) {
+	defer recoverInvoke("_NewEnum", &err)
+
+	err = oleutil.ForEach(o.v.ToIDispatch(), func(v *ole.VARIANT) error {
+		item := comResult(v)
+		if obj, ok := item.(Object); ok {
+			defer obj.Release()
+		}
+		return fn(item)
+	})
+	return classifyCOMError(err)
+}
+
+// Release clears the variant of an object returned by Call or Get.
+func (o *comObject) Release() {
+	if o.owned && o.v != nil {
+		o.v.Clear()
+		o.v = nil
+	}
+}
+
+// comArgs replaces Object arguments with their IDispatch and passes
+// dates, decimals and Неопределено as variants
+func comArgs(args []any) []any {
+	res := make([]any, len(args))
+	for i, arg := range args {
+		switch val := arg.(type) {
+		case nil:
+			// go-ole passes nil as VT_NULL, which is Null in 1C
+			res[i] = &ole.VARIANT{VT: ole.VT_EMPTY}
+		case *comObject:
+			res[i] = val.v.ToIDispatch()
+		case time.Time:
+			res[i] = dateVariant(val)
+		case Decimal:
+			if v := decimalVariant(val); v != nil {
+				res[i] = v
+			} else {
+				res[i] = val.Float64()
+			}
+		default:
+			res[i] = arg
+		}
+	}
+	return res
+}
+
+// comResult wraps a dispatch result into Object and converts any other
+// result into a Go value, clearing the variant
+func comResult(v *ole.VARIANT) any {
+	if v.VT == ole.VT_DISPATCH {
+		return &comObject{v: v, owned: true}
+	}
+	defer v.Clear()
+	if v.VT == ole.VT_DECIMAL {
+		return variantDecimal(v)
+	}
+	if v.VT == ole.VT_ARRAY|ole.VT_UI1 {
+		return v.ToArray().ToByteArray()
+	}
+	return v.Value()
+}
+
+// recoverInvoke turns a go-ole panic on an argument of unsupported type
+// into an error, so it does not take the worker down
+func recoverInvoke(name string, err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("%s: %v", name, r)
+	}
+}
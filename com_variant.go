@@ -0,0 +1,80 @@
+package gocom1c
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+)
+
+// go-ole passes time.Time as a string and reads VT_DECIMAL as nil,
+// so both are converted here. A VARIANT holding DECIMAL is laid out as
+// VT(2) scale(1) sign(1) hi32(4) lo64(8), in the bytes go-ole keeps unexported.
+
+// oleEpoch is day zero of OLE automation dates
+var oleEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// maxDecimalUnscaled is the largest unscaled value of OLE DECIMAL, 2^96-1
+var maxDecimalUnscaled = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 96), big.NewInt(1))
+
+// dateVariant returns a VT_DATE variant of the wall clock time of t
+func dateVariant(t time.Time) *ole.VARIANT {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	days := float64((wall.Unix() - oleEpoch.Unix()) / 86400)
+	dayTime := float64(t.Hour()*3600+t.Minute()*60+t.Second()) / 86400
+	// the fraction of negative dates counts forward from their day
+	if days < 0 {
+		days -= dayTime
+	} else {
+		days += dayTime
+	}
+
+	v := ole.NewVariant(ole.VT_DATE, int64(math.Float64bits(days)))
+	return &v
+}
+
+// decimalVariant returns a VT_DECIMAL variant of d, or nil when d does
+// not fit into OLE DECIMAL
+func decimalVariant(d Decimal) *ole.VARIANT {
+	s := d.String()
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	intPart, frac, _ := strings.Cut(s, ".")
+	unscaled, ok := new(big.Int).SetString(intPart+frac, 10)
+	if !ok || len(frac) > 28 || unscaled.Cmp(maxDecimalUnscaled) > 0 {
+		return nil
+	}
+
+	var buf [16]byte
+	binary.LittleEndian.PutUint16(buf[0:2], uint16(ole.VT_DECIMAL))
+	buf[2] = byte(len(frac))
+	if neg {
+		buf[3] = 0x80
+	}
+	lo := new(big.Int).And(unscaled, new(big.Int).SetUint64(math.MaxUint64))
+	hi := new(big.Int).Rsh(unscaled, 64)
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(hi.Uint64()))
+	binary.LittleEndian.PutUint64(buf[8:16], lo.Uint64())
+
+	v := new(ole.VARIANT)
+	copy((*[16]byte)(unsafe.Pointer(v))[:], buf[:])
+	return v
+}
+
+// variantDecimal reads a VT_DECIMAL variant
+func variantDecimal(v *ole.VARIANT) Decimal {
+	raw := (*[16]byte)(unsafe.Pointer(v))
+	scale := int(raw[2])
+	neg := raw[3]&0x80 != 0
+	hi := binary.LittleEndian.Uint32(raw[4:8])
+	lo := binary.LittleEndian.Uint64(raw[8:16])
+
+	unscaled := new(big.Int).Lsh(new(big.Int).SetUint64(uint64(hi)), 64)
+	unscaled.Or(unscaled, new(big.Int).SetUint64(lo))
+	return decimalFromParts(unscaled, scale, neg)
+}
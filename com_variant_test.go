@@ -0,0 +1,99 @@
+package gocom1c
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+)
+
+func TestDateVariant(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*3600)
+	tests := []struct {
+		t    time.Time
+		want float64
+	}{
+		{time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC), 0},
+		{time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC), 2},
+		{time.Date(2024, 3, 15, 18, 0, 0, 0, time.UTC), 45366.75},
+		// the wall clock is passed, 1C dates have no zone
+		{time.Date(2024, 3, 15, 18, 0, 0, 0, moscow), 45366.75},
+		{time.Date(2024, 3, 15, 18, 0, 0, 999, time.UTC), 45366.75},
+		// the fraction of days before the epoch counts forward
+		{time.Date(1899, 12, 29, 6, 0, 0, 0, time.UTC), -1.25},
+		{time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC), -693593},
+	}
+	for _, tt := range tests {
+		v := dateVariant(tt.t)
+		if v.VT != ole.VT_DATE {
+			t.Fatalf("dateVariant(%v).VT = %v", tt.t, v.VT)
+		}
+		if got := math.Float64frombits(uint64(v.Val)); got != tt.want {
+			t.Errorf("dateVariant(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalVariant(t *testing.T) {
+	for _, d := range []Decimal{
+		"0", "1", "-1.5", "123.45", "-0.0000000001",
+		"79228162514264337593543950335",
+		"-7.9228162514264337593543950335",
+		"0.0000000000000000000000000001",
+	} {
+		v := decimalVariant(d)
+		if v == nil {
+			t.Errorf("decimalVariant(%q) = nil", d)
+			continue
+		}
+		if v.VT != ole.VT_DECIMAL {
+			t.Errorf("decimalVariant(%q).VT = %v", d, v.VT)
+		}
+		if got := variantDecimal(v); got != d {
+			t.Errorf("variantDecimal(decimalVariant(%q)) = %q", d, got)
+		}
+	}
+
+	// the layout is the one of OLE DECIMAL
+	raw := (*[16]byte)(unsafe.Pointer(decimalVariant("-1.5")))
+	if scale, sign := raw[2], raw[3]; scale != 1 || sign != 0x80 {
+		t.Errorf("scale = %d, sign = %#x", scale, sign)
+	}
+	if hi, lo := binary.LittleEndian.Uint32(raw[4:8]), binary.LittleEndian.Uint64(raw[8:16]); hi != 0 || lo != 15 {
+		t.Errorf("hi = %d, lo = %d", hi, lo)
+	}
+
+	// numbers OLE DECIMAL cannot hold are passed as float
+	for _, d := range []Decimal{
+		"79228162514264337593543950336",
+		"0.00000000000000000000000000001",
+	} {
+		if v := decimalVariant(d); v != nil {
+			t.Errorf("decimalVariant(%q) = %q, want nil", d, variantDecimal(v))
+		}
+	}
+}
+
+func TestComResultDecimal(t *testing.T) {
+	v := decimalVariant("-42.01")
+	if got := comResult(v); got != Decimal("-42.01") {
+		t.Errorf("comResult = %#v", got)
+	}
+
+	args := comArgs([]any{Decimal("1e30"), Decimal("2.5"), time.Time{}, nil})
+	if f, ok := args[0].(float64); !ok || f != 1e30 {
+		t.Errorf("an overflowing decimal is passed as %#v", args[0])
+	}
+	if v, ok := args[1].(*ole.VARIANT); !ok || v.VT != ole.VT_DECIMAL {
+		t.Errorf("a decimal is passed as %#v", args[1])
+	}
+	if v, ok := args[2].(*ole.VARIANT); !ok || v.VT != ole.VT_DATE {
+		t.Errorf("a date is passed as %#v", args[2])
+	}
+	if v, ok := args[3].(*ole.VARIANT); !ok || v.VT != ole.VT_EMPTY {
+		t.Errorf("nil is passed as %#v", args[3])
+	}
+}
@@ -1,6 +1,9 @@
 package gocom1c
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 const (
 	defMinPoopSize        = 1
@@ -10,6 +13,31 @@ const (
 	defWaitConnTimeoutSec = 10
 	defCleanupIdleConnSec = 60
 	defConnCloseTimeout   = 30
+	defCommandTimeoutSec  = 5 * 60
+	defReconnectMinDelay  = 1 * time.Second
+	defReconnectMaxDelay  = 1 * time.Minute
+	defHealthCheckIdle    = 30 * time.Second
+	defTempFileMaxAge     = 24 * time.Hour
+	defFailbackInterval   = 1 * time.Minute
+	defUserPoolMaxSize    = 1
+	defUserPoolIdle       = 10 * time.Minute
+	defMaxUserPools       = 10
+	defSessionIdleTimeout = 5 * time.Minute
+)
+
+// Sources of the command processing
+const (
+	// SourceCatalog reads the processing named CommandExec from
+	// Справочники.ДополнительныеОтчетыИОбработки
+	SourceCatalog = "catalog"
+	// SourceFile creates the processing from ProcessingFile
+	SourceFile = "file"
+	// SourceExtension creates Обработки.<CommandExec>, a processing of
+	// the configuration or of an attached extension
+	SourceExtension = "extension"
+	// SourceEmbedded writes ProcessingData to a temporary file once
+	// and creates the processing from it
+	SourceEmbedded = "embedded"
 )
 
 // Config holds configuration for COM pool
@@ -23,6 +51,89 @@ type Config struct {
 	WaitConnTimeout  time.Duration
 	CleanupIdleConn  time.Duration
 	ConnCloseTimeout time.Duration
+	CommandTimeout   time.Duration // a hung call quarantines its connection, negative disables
+	MaxQuarantined   int           // quarantined connections replaced beyond MaxPoolSize
+	Backend          Backend       // COMBackend
+
+	// CommonModules names server common modules with external connection
+	// enabled. When set, no processing is loaded and commands are called
+	// as Module.Method(params) on the connection. A command without
+	// a module goes to the first one.
+	CommonModules []string
+
+	// ProcessingSource tells where the command processing comes from,
+	// see SourceCatalog and others. When empty it is SourceEmbedded
+	// if ProcessingData is set, SourceFile if ProcessingFile is set
+	// and SourceCatalog otherwise.
+	ProcessingSource string
+	// ProcessingFile is the path of a local .epf for SourceFile
+	ProcessingFile string
+	// ProcessingData is the .epf content for SourceEmbedded,
+	// usually embedded into the binary with go:embed
+	ProcessingData []byte
+
+	// Reconnect backoff after a broken connection is discarded
+	ReconnectMinDelay time.Duration
+	ReconnectMaxDelay time.Duration
+	// A connection idle longer than HealthCheckIdle is probed before
+	// it is handed out. Idle connections are also probed every
+	// HealthCheckInterval, zero disables background probes.
+	HealthCheckIdle     time.Duration
+	HealthCheckInterval time.Duration
+	// PingCommand is sent to the processing as a probe, when empty
+	// a property of the connection object is read instead
+	PingCommand string
+	// A connection older than MaxConnLifetime or used more than
+	// MaxConnUses times is retired after its current call and replaced.
+	// Lifetimes are shortened by a random part of ConnLifetimeJitter
+	// so that connections created together are not retired together.
+	MaxConnLifetime    time.Duration
+	MaxConnUses        int64
+	ConnLifetimeJitter time.Duration
+	// LazyStart makes NewCOMPool return at once with an empty pool,
+	// which is filled up to MinPoolSize in background. See COMPool.WaitReady.
+	LazyStart bool
+	// The processing version is checked every ReloadCheckInterval, zero
+	// disables checks. When it changes, the processing is reloaded on
+	// every connection, see COMPool.Reload. The version is read from
+	// the processing source or, when VersionCommand is set, returned
+	// by this command of the processing.
+	ReloadCheckInterval time.Duration
+	VersionCommand      string
+	// Endpoints are connection strings of the same infobase, such as
+	// of two cluster managers or of a standby copy, in the order of
+	// preference. When connecting fails, the next one is tried and used
+	// from then on. While a standby one is used, the preferred ones are
+	// probed every FailbackInterval and the pool switches back to the
+	// first recovered. When empty, ConnectionString is the only endpoint.
+	Endpoints        []string
+	FailbackInterval time.Duration
+	// Temporary files of the pool are kept in a directory of its own in
+	// TempDir, os.TempDir() when empty. Directories of pools gone for more
+	// than TempFileMaxAge are deleted when the pool starts, see TempFiles.
+	TempDir        string
+	TempFileMaxAge time.Duration
+	// Sub-pools acting as other 1C users, see COMPool.AsUser, have up to
+	// UserPoolMaxSize connections each and are closed after
+	// UserPoolIdleTimeout without calls. At most MaxUserPools of them
+	// are open, so the sub-pools hold up to MaxUserPools*UserPoolMaxSize
+	// 1C sessions.
+	UserPoolMaxSize     int
+	UserPoolIdleTimeout time.Duration
+	MaxUserPools        int
+	// A session started by COMPool.BeginSession without its own idle
+	// timeout is rolled back after SessionIdleTimeout without calls.
+	SessionIdleTimeout time.Duration
+	// IdempotentCommands are retried once on a fresh connection
+	// when the connection breaks during the call
+	IdempotentCommands []string
+
+	tempFiles *TempFiles // set by NewCOMPool
+}
+
+// IsIdempotent reports whether the command is safe to retry
+func (cfg *Config) IsIdempotent(command string) bool {
+	return slices.Contains(cfg.IdempotentCommands, command)
 }
 
 func (cfg *Config) SetDefaults() {
@@ -47,7 +158,58 @@ func (cfg *Config) SetDefaults() {
 	if cfg.ConnCloseTimeout <= 0 {
 		cfg.ConnCloseTimeout = defConnCloseTimeout * time.Second
 	}
+	if cfg.CommandTimeout == 0 {
+		// a negative timeout is kept, it disables the limit
+		cfg.CommandTimeout = defCommandTimeoutSec * time.Second
+	}
+	if cfg.MaxQuarantined == 0 {
+		// a negative limit is kept, no hung connection is replaced then
+		cfg.MaxQuarantined = cfg.MaxPoolSize
+	}
+	if cfg.ReconnectMinDelay <= 0 {
+		cfg.ReconnectMinDelay = defReconnectMinDelay
+	}
+	if cfg.ReconnectMaxDelay < cfg.ReconnectMinDelay {
+		cfg.ReconnectMaxDelay = max(defReconnectMaxDelay, cfg.ReconnectMinDelay)
+	}
+	if cfg.HealthCheckIdle <= 0 {
+		cfg.HealthCheckIdle = defHealthCheckIdle
+	}
+	if cfg.MaxConnLifetime > 0 && cfg.ConnLifetimeJitter <= 0 {
+		cfg.ConnLifetimeJitter = cfg.MaxConnLifetime / 10
+	}
+	if cfg.FailbackInterval <= 0 {
+		cfg.FailbackInterval = defFailbackInterval
+	}
+	if cfg.UserPoolMaxSize <= 0 {
+		cfg.UserPoolMaxSize = defUserPoolMaxSize
+	}
+	if cfg.UserPoolIdleTimeout <= 0 {
+		cfg.UserPoolIdleTimeout = defUserPoolIdle
+	}
+	if cfg.MaxUserPools <= 0 {
+		cfg.MaxUserPools = defMaxUserPools
+	}
+	if cfg.SessionIdleTimeout <= 0 {
+		cfg.SessionIdleTimeout = defSessionIdleTimeout
+	}
+	if cfg.TempFileMaxAge <= 0 {
+		cfg.TempFileMaxAge = defTempFileMaxAge
+	}
 	if cfg.COMObjectID == "" {
 		cfg.COMObjectID = defComObject
 	}
+	if cfg.Backend == nil {
+		cfg.Backend = COMBackend{}
+	}
+	if cfg.ProcessingSource == "" {
+		switch {
+		case len(cfg.ProcessingData) > 0:
+			cfg.ProcessingSource = SourceEmbedded
+		case cfg.ProcessingFile != "":
+			cfg.ProcessingSource = SourceFile
+		default:
+			cfg.ProcessingSource = SourceCatalog
+		}
+	}
 }
@@ -3,23 +3,33 @@ package gocom1c
 import (
 	"sync"
 	"time"
-
-	"github.com/go-ole/go-ole"
 )
 
 // COMConnection represents a single COM connection
 type COMConnection struct {
-	id                int
-	v8                *ole.VARIANT
-	commandExecParent *ole.VARIANT
-	commandExec       *ole.VARIANT
-	wg                sync.WaitGroup // all
-	quit              chan struct{}
-	commands          chan func()
-	lastUsed          time.Time
-	useCount          int64
-	busy              bool
-	mutex             sync.RWMutex
+	id       int
+	session  Session        // owned by the worker goroutine
+	wg       sync.WaitGroup // all
+	quit     chan struct{}
+	quitOnce sync.Once
+	commands chan func()
+	timeout  time.Duration // command execution timeout, none when not positive
+	created  time.Time
+	expires  time.Time // zero when lifetime is not limited
+	maxUses  int64     // zero when use count is not limited
+	lastUsed time.Time
+	checked  time.Time // last successful health probe
+	useCount int64
+	busy     bool
+	tainted  bool // a call was abandoned while running
+	broken   bool // the 1C session is lost
+	mutex    sync.RWMutex
+
+	quarantinedAt time.Time
+	generation    uint64 // pool generation of the processing, see COMPool.Reload
+	endpoint      int    // index of the endpoint connected to
+	endpointName  string
+	callTimer     *time.Timer // timeout of the running call, owned by the worker
 }
 
 // GetID returns the connection ID
@@ -41,6 +51,64 @@ func (c *COMConnection) GetLastUsed() time.Time {
 	return c.lastUsed
 }
 
+// status returns the connection state for ConnStatuses
+func (c *COMConnection) status() map[string]any {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	state := "idle"
+	switch {
+	case !c.quarantinedAt.IsZero():
+		state = "quarantined"
+	case c.busy:
+		state = "busy"
+	}
+
+	stat := map[string]any{
+		"state":     state,
+		"useCount":  c.useCount,
+		"lastUsed":  c.lastUsed,
+		"createdAt": c.created,
+		"endpoint":  c.endpointName,
+	}
+	if !c.quarantinedAt.IsZero() {
+		stat["quarantinedAt"] = c.quarantinedAt
+	}
+	return stat
+}
+
+// needsCheck reports whether the connection has been neither used
+// nor probed for longer than d
+func (c *COMConnection) needsCheck(d time.Duration) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return time.Since(c.lastUsed) > d && time.Since(c.checked) > d
+}
+
+// expired reports whether the connection has outlived its lifetime
+// or use count and must be retired
+func (c *COMConnection) expired() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return (!c.expires.IsZero() && time.Now().After(c.expires)) ||
+		(c.maxUses > 0 && c.useCount >= c.maxUses)
+}
+
+// IsTainted returns whether a call on the connection was abandoned mid-flight
+func (c *COMConnection) IsTainted() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.tainted
+}
+
+// lost reports whether the 1C session of the connection is lost
+// or stuck in an abandoned call
+func (c *COMConnection) lost() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.broken || c.tainted
+}
+
 func (c *COMConnection) GetUseCount() int64 {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
@@ -0,0 +1,38 @@
+package gocom1c
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetireByUses(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, MaxConnUses: 2})
+
+	for i := range 4 {
+		if _, err := pool.ExecuteCommand("Ping", "{}"); err != nil {
+			t.Fatalf("ExecuteCommand %d: %v", i, err)
+		}
+	}
+	if n := pool.Stats().Retired; n != 2 {
+		t.Fatalf("Retired = %d, want 2", n)
+	}
+	waitFor(t, "replacement connections", func() bool { return b.Opened() == 3 && pool.Stats().Idle == 1 })
+}
+
+func TestRetireByLifetime(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, MaxConnLifetime: 100 * time.Millisecond})
+	time.Sleep(120 * time.Millisecond)
+
+	// the expired connection is retired when borrowed
+	if _, err := pool.ExecuteCommand("Ping", "{}"); err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	if n := pool.Stats().Retired; n != 1 {
+		t.Fatalf("Retired = %d, want 1", n)
+	}
+	if n := b.Opened(); n < 2 {
+		t.Fatalf("Opened = %d, want a replacement connection", n)
+	}
+}
@@ -0,0 +1,199 @@
+package gocom1c
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// redactedSecret replaces passwords in the redacted form
+const redactedSecret = "***"
+
+// Keys of the connection string parsed into ConnectionString fields
+const (
+	keyFile     = "File"
+	keyServer   = "Srvr"
+	keyRef      = "Ref"
+	keyUser     = "Usr"
+	keyPassword = "Pwd"
+)
+
+// secretKeys are keys whose values are masked by Redacted,
+// in lower case: the infobase and the web server passwords
+var secretKeys = []string{"pwd", "wsp"}
+
+// secretPattern finds secrets in strings that can not be parsed
+var secretPattern = regexp.MustCompile(`(?i)\b(pwd|wsp)\s*=\s*("(?:[^"]|"")*"?|[^;]*)`)
+
+// ConnectionString is a 1C infobase connection string, either of
+// a server infobase, Srvr="server";Ref="base", or of a file one,
+// File="path". Use String to get the string for Config.ConnectionString.
+type ConnectionString struct {
+	File     string // File, the directory of a file infobase
+	Server   string // Srvr, the cluster of a server infobase
+	Ref      string // Ref, the infobase name in the cluster
+	User     string // Usr
+	Password string // Pwd
+	// Params holds other keys, such as Locale or prmod
+	Params map[string]string
+}
+
+// ParseConnectionString parses a connection string. Keys are case
+// insensitive, values are plain or quoted with inner quotes doubled.
+func ParseConnectionString(s string) (*ConnectionString, error) {
+	cs := &ConnectionString{}
+	rest := s
+	for {
+		rest = strings.TrimLeft(rest, " \t\r\n;")
+		if rest == "" {
+			return cs, nil
+		}
+
+		key, value, ok := strings.Cut(rest, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.Contains(key, ";") {
+			// the text itself is not shown, it may be a part of a password
+			return nil, fmt.Errorf("connection string: expected key=value at position %d", len(s)-len(rest)+1)
+		}
+
+		var err error
+		if value, rest, err = parseConnValue(value); err != nil {
+			return nil, fmt.Errorf("connection string: %s: %w", key, err)
+		}
+		cs.set(key, value)
+	}
+}
+
+// parseConnValue reads a value up to the next ';' and returns it
+// with the rest of the string
+func parseConnValue(s string) (string, string, error) {
+	s = strings.TrimLeft(s, " \t")
+	if !strings.HasPrefix(s, `"`) {
+		value, rest, _ := strings.Cut(s, ";")
+		return strings.TrimSpace(value), rest, nil
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != '"' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '"' {
+			// a doubled quote
+			b.WriteByte('"')
+			i++
+			continue
+		}
+		rest := strings.TrimLeft(s[i+1:], " \t")
+		if rest != "" && rest[0] != ';' {
+			return "", "", fmt.Errorf("unexpected text after the closing quote")
+		}
+		return b.String(), strings.TrimPrefix(rest, ";"), nil
+	}
+	return "", "", fmt.Errorf("missing closing quote")
+}
+
+func (cs *ConnectionString) set(key string, value string) {
+	switch strings.ToLower(key) {
+	case "file":
+		cs.File = value
+	case "srvr":
+		cs.Server = value
+	case "ref":
+		cs.Ref = value
+	case "usr":
+		cs.User = value
+	case "pwd":
+		cs.Password = value
+	default:
+		if cs.Params == nil {
+			cs.Params = make(map[string]string)
+		}
+		cs.Params[key] = value
+	}
+}
+
+// Validate checks that the string names either a server infobase
+// or a file one.
+func (cs *ConnectionString) Validate() error {
+	switch {
+	case cs.File != "" && (cs.Server != "" || cs.Ref != ""):
+		return fmt.Errorf("connection string: File can not be combined with Srvr and Ref")
+	case cs.File != "":
+		return nil
+	case cs.Server == "" && cs.Ref == "":
+		return fmt.Errorf("connection string: either Srvr and Ref or File is required")
+	case cs.Server == "":
+		return fmt.Errorf("connection string: Srvr is required with Ref")
+	case cs.Ref == "":
+		return fmt.Errorf("connection string: Ref is required with Srvr")
+	}
+	return nil
+}
+
+// String builds the connection string, quoting every value.
+func (cs *ConnectionString) String() string {
+	return cs.build(false)
+}
+
+// Redacted builds the connection string with passwords masked,
+// for logs, status and errors.
+func (cs *ConnectionString) Redacted() string {
+	return cs.build(true)
+}
+
+func (cs *ConnectionString) build(redact bool) string {
+	var parts []string
+	add := func(key string, value string) {
+		if value == "" {
+			return
+		}
+		if redact && isSecretKey(key) {
+			value = redactedSecret
+		}
+		parts = append(parts, key+`="`+strings.ReplaceAll(value, `"`, `""`)+`"`)
+	}
+
+	add(keyFile, cs.File)
+	add(keyServer, cs.Server)
+	add(keyRef, cs.Ref)
+	add(keyUser, cs.User)
+	add(keyPassword, cs.Password)
+
+	keys := make([]string, 0, len(cs.Params))
+	for key := range cs.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		add(key, cs.Params[key])
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, ";") + ";"
+}
+
+func isSecretKey(key string) bool {
+	return slices.Contains(secretKeys, strings.ToLower(key))
+}
+
+// RedactConnectionString masks passwords in a raw connection string.
+// A string that can not be parsed has its password values masked as is.
+func RedactConnectionString(s string) string {
+	cs, err := ParseConnectionString(s)
+	if err != nil {
+		return secretPattern.ReplaceAllString(s, `$1="`+redactedSecret+`"`)
+	}
+	return cs.Redacted()
+}
+
+// ConnectionString returns the connection string of the endpoint
+// the pool uses with passwords masked.
+func (p *COMPool) ConnectionString() string {
+	return p.endpoints[p.endpoint.Load()].name
+}
@@ -0,0 +1,203 @@
+package gocom1c
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseConnectionString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ConnectionString
+	}{
+		{
+			in:   `Srvr="srv1c";Ref="buh";Usr="Администратор";Pwd="secret";`,
+			want: ConnectionString{Server: "srv1c", Ref: "buh", User: "Администратор", Password: "secret"},
+		},
+		{
+			// keys are case insensitive, other keys keep their case
+			in:   `SRVR=srv1c; ref = buh ;usr="Иванов";PWD=;Locale=ru_RU`,
+			want: ConnectionString{Server: "srv1c", Ref: "buh", User: "Иванов", Params: map[string]string{"Locale": "ru_RU"}},
+		},
+		{
+			// doubled quotes are one quote
+			in:   `File="C:\Базы\Торговля";Usr="ООО ""Ромашка""";Pwd="a""b"`,
+			want: ConnectionString{File: `C:\Базы\Торговля`, User: `ООО "Ромашка"`, Password: `a"b`},
+		},
+		{
+			// ';' and '=' inside quotes are a part of the value
+			in:   `Srvr="srv1c:1541";Ref="buh";Pwd="p;w=d";prmod="1"`,
+			want: ConnectionString{Server: "srv1c:1541", Ref: "buh", Password: "p;w=d", Params: map[string]string{"prmod": "1"}},
+		},
+		{
+			in:   `  ;;Srvr = "srv" ; Ref="" ;`,
+			want: ConnectionString{Server: "srv"},
+		},
+		{
+			in:   "",
+			want: ConnectionString{},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseConnectionString(tt.in)
+		if err != nil {
+			t.Errorf("ParseConnectionString(%q): %v", tt.in, err)
+			continue
+		}
+		if got.File != tt.want.File || got.Server != tt.want.Server || got.Ref != tt.want.Ref ||
+			got.User != tt.want.User || got.Password != tt.want.Password || !maps.Equal(got.Params, tt.want.Params) {
+			t.Errorf("ParseConnectionString(%q) = %+v, want %+v", tt.in, *got, tt.want)
+		}
+	}
+}
+
+func TestParseConnectionStringErrors(t *testing.T) {
+	tests := []string{
+		`Srvr="srv";Pwd="unterminated`,
+		`Srvr="srv" x;Ref="buh"`,
+		`Srvr="srv";secret;Ref="buh"`,
+		`="srv"`,
+	}
+	for _, in := range tests {
+		_, err := ParseConnectionString(in)
+		if err == nil {
+			t.Errorf("ParseConnectionString(%q) succeeded", in)
+			continue
+		}
+		for _, secret := range []string{"unterminated", "secret"} {
+			if strings.Contains(err.Error(), secret) {
+				t.Errorf("ParseConnectionString(%q) = %v, shows the string", in, err)
+			}
+		}
+	}
+}
+
+func TestConnectionStringRoundTrip(t *testing.T) {
+	tests := []string{
+		`Srvr="srv1c";Ref="buh";Usr="Администратор";Pwd="secret";`,
+		`File="C:\Базы\Торговля";Usr="ООО ""Ромашка""";Pwd="p;w=""d""";`,
+		`Srvr="srv";Ref="buh";Locale="ru_RU";prmod="1";`,
+	}
+	for _, in := range tests {
+		cs, err := ParseConnectionString(in)
+		if err != nil {
+			t.Fatalf("ParseConnectionString(%q): %v", in, err)
+		}
+		out := cs.String()
+		if out != in {
+			t.Errorf("String() = %q, want %q", out, in)
+		}
+		again, err := ParseConnectionString(out)
+		if err != nil || again.String() != out || again.Password != cs.Password || again.User != cs.User {
+			t.Errorf("ParseConnectionString(%q) = %+v, %v, want %+v", out, again, err, cs)
+		}
+	}
+}
+
+func TestConnectionStringValidate(t *testing.T) {
+	tests := []struct {
+		cs ConnectionString
+		ok bool
+	}{
+		{ConnectionString{Server: "srv", Ref: "buh"}, true},
+		{ConnectionString{File: `C:\base`}, true},
+		{ConnectionString{File: `C:\base`, Server: "srv"}, false},
+		{ConnectionString{Server: "srv"}, false},
+		{ConnectionString{Ref: "buh"}, false},
+		{ConnectionString{User: "Иванов"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.cs.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v", tt.cs, err)
+		}
+	}
+}
+
+func TestRedactConnectionString(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{
+			in:   `Srvr="srv";Ref="buh";Usr="Иванов";Pwd="secret";`,
+			want: `Srvr="srv";Ref="buh";Usr="Иванов";Pwd="***";`,
+		},
+		{
+			in:   `srvr=srv;ref=buh;pwd=secret;wsp="web;secret"`,
+			want: `Srvr="srv";Ref="buh";Pwd="***";wsp="***";`,
+		},
+		{
+			in:   `Srvr="srv";Ref="buh"`,
+			want: `Srvr="srv";Ref="buh";`,
+		},
+		{
+			// a string that can not be parsed is masked as is
+			in:   `Srvr="srv" x;PWD = "sec""ret";Wsp=secret`,
+			want: `Srvr="srv" x;PWD="***";Wsp="***"`,
+		},
+		{
+			in:   `Srvr="srv";Pwd="secret`,
+			want: `Srvr="srv";Pwd="***"`,
+		},
+	}
+	for _, tt := range tests {
+		if got := RedactConnectionString(tt.in); got != tt.want {
+			t.Errorf("RedactConnectionString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// recordLogger keeps the pool logs
+type recordLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordLogger) add(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *recordLogger) Infof(format string, args ...any)  { l.add(format, args...) }
+func (l *recordLogger) Errorf(format string, args ...any) { l.add(format, args...) }
+func (l *recordLogger) Warnf(format string, args ...any)  { l.add(format, args...) }
+func (l *recordLogger) Debugf(format string, args ...any) { l.add(format, args...) }
+
+func TestConnectionStringRedactedInLogs(t *testing.T) {
+	primary := `Srvr="srv1";Ref="buh";Usr="Иванов";Pwd="first-secret";`
+	standby := `Srvr="srv2";Ref="buh";Usr="Иванов";Pwd="second-secret";`
+	b := &FakeBackend{}
+	b.SetDown(primary, true)
+
+	logger := &recordLogger{}
+	cfg := &Config{Backend: b, Endpoints: []string{primary, standby}, TempDir: t.TempDir(), MinPoolSize: 1}
+	pool, err := NewCOMPool(cfg, logger)
+	if err != nil {
+		t.Fatalf("NewCOMPool: %v", err)
+	}
+	if got := pool.ConnectionString(); got != `Srvr="srv2";Ref="buh";Usr="Иванов";Pwd="***";` {
+		t.Errorf("ConnectionString = %q", got)
+	}
+	pool.Close()
+
+	// nothing can connect, the error names the endpoints
+	b.SetDown(standby, true)
+	cfg = &Config{Backend: b, Endpoints: []string{primary, standby}, TempDir: t.TempDir(), MinPoolSize: 1}
+	if pool, err = NewCOMPool(cfg, logger); err == nil {
+		pool.Close()
+		t.Fatal("NewCOMPool connected to endpoints that are down")
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logs := strings.Join(append(logger.lines, err.Error()), "\n")
+	if !strings.Contains(logs, `Srvr="srv1"`) || !strings.Contains(logs, `Pwd="***"`) {
+		t.Fatalf("the endpoints are not logged:\n%s", logs)
+	}
+	if strings.Contains(logs, "first-secret") || strings.Contains(logs, "second-secret") {
+		t.Fatalf("a password is logged:\n%s", logs)
+	}
+}
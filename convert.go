@@ -0,0 +1,419 @@
+package gocom1c
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// identifierPattern matches names that can be keys of a Структура
+var identifierPattern = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_]*$`)
+
+// Converter maps Go values to 1C values and back. It must be used on
+// the worker goroutine, inside Do, with the connection object of
+// the session. Objects it creates for arguments are kept until Release.
+//
+// Go to 1C: nil is Неопределено, slices and arrays are Массив, maps with
+// identifier keys and structs are Структура, other maps are Соответствие,
+// []byte is ДвоичныеДанные. Struct fields are named as by encoding/json.
+//
+// 1C to Go: numbers are Decimal, Дата is time.Time, Массив is []any,
+// Структура and Соответствие are map[string]any, ТаблицаЗначений is
+// []map[string]any, ДвоичныеДанные is []byte, other objects such as refs
+// and enums are their XML string, which is the UUID for refs.
+type Converter struct {
+	conn    Object
+	created []Object
+}
+
+// NewConverter returns a converter working through the connection object
+func NewConverter(conn Object) *Converter {
+	return &Converter{conn: conn}
+}
+
+// Release releases the objects created by ToOneC.
+func (c *Converter) Release() {
+	for _, obj := range c.created {
+		obj.Release()
+	}
+	c.created = nil
+}
+
+// ToOneC converts a Go value into an argument of a 1C call.
+func (c *Converter) ToOneC(v any) (any, error) {
+	switch val := v.(type) {
+	case nil, bool, string, time.Time, Decimal, Object,
+		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return val, nil
+	case json.Number:
+		return ParseDecimal(val.String())
+	case []byte:
+		return c.binaryData(val)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return c.ToOneC(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, nil
+		}
+		return c.array(rv)
+	case reflect.Map:
+		return c.mapping(rv)
+	case reflect.Struct:
+		return c.structure(rv)
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// newObject creates a 1C object with Новый and keeps it for Release
+func (c *Converter) newObject(typeName string) (Object, error) {
+	obj, err := callObject(c.conn, "NewObject", typeName)
+	if err != nil {
+		return nil, err
+	}
+	c.created = append(c.created, obj)
+	return obj, nil
+}
+
+// binaryData creates ДвоичныеДанные through Base64Значение
+func (c *Converter) binaryData(b []byte) (any, error) {
+	obj, err := callObject(c.conn, "Base64Значение", base64.StdEncoding.EncodeToString(b))
+	if err != nil {
+		return nil, err
+	}
+	c.created = append(c.created, obj)
+	return obj, nil
+}
+
+func (c *Converter) array(rv reflect.Value) (any, error) {
+	arr, err := c.newObject("Массив")
+	if err != nil {
+		return nil, err
+	}
+	for i := range rv.Len() {
+		item, err := c.ToOneC(rv.Index(i).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		if _, err := arr.Call("Добавить", item); err != nil {
+			return nil, err
+		}
+	}
+	return arr, nil
+}
+
+// mapping creates Структура when all keys are identifiers,
+// Соответствие otherwise
+func (c *Converter) mapping(rv reflect.Value) (any, error) {
+	keys := rv.MapKeys()
+	isStructure := rv.Type().Key().Kind() == reflect.String
+	for _, key := range keys {
+		if !isStructure || !identifierPattern.MatchString(key.String()) {
+			isStructure = false
+			break
+		}
+	}
+
+	typeName := "Соответствие"
+	if isStructure {
+		typeName = "Структура"
+		// keep the order of keys stable
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	}
+	obj, err := c.newObject(typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		k, err := c.ToOneC(key.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("key %v: %w", key, err)
+		}
+		val, err := c.ToOneC(rv.MapIndex(key).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", key, err)
+		}
+		if _, err := obj.Call("Вставить", k, val); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+func (c *Converter) structure(rv reflect.Value) (any, error) {
+	obj, err := c.newObject("Структура")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.structFields(obj, rv); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// structFields inserts exported struct fields into obj, flattening
+// embedded structs and following json tags
+func (c *Converter) structFields(obj Object, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		fv := rv.Field(i)
+		if field.Anonymous && name == "" && fv.Kind() == reflect.Struct {
+			if err := c.structFields(obj, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+
+		val, err := c.ToOneC(fv.Interface())
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if _, err := obj.Call("Вставить", name, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromOneC converts a result of a 1C call into a Go value.
+// v is released when it is an object.
+func (c *Converter) FromOneC(v any) (any, error) {
+	switch val := v.(type) {
+	case int:
+		return DecimalFromInt(int64(val)), nil
+	case int8:
+		return DecimalFromInt(int64(val)), nil
+	case int16:
+		return DecimalFromInt(int64(val)), nil
+	case int32:
+		return DecimalFromInt(int64(val)), nil
+	case int64:
+		return DecimalFromInt(val), nil
+	case uint8:
+		return DecimalFromInt(int64(val)), nil
+	case uint16:
+		return DecimalFromInt(int64(val)), nil
+	case uint32:
+		return DecimalFromInt(int64(val)), nil
+	case uint:
+		return Decimal(strconv.FormatUint(uint64(val), 10)), nil
+	case uint64:
+		return Decimal(strconv.FormatUint(val, 10)), nil
+	case float32:
+		return DecimalFromFloat(float64(val)), nil
+	case float64:
+		return DecimalFromFloat(val), nil
+	case Object:
+		defer val.Release()
+		return c.object(val)
+	default:
+		return val, nil
+	}
+}
+
+// object converts an object by its XML type name
+func (c *Converter) object(obj Object) (any, error) {
+	switch c.typeName(obj) {
+	case "Array", "FixedArray":
+		return c.fromArray(obj)
+	case "Structure", "FixedStructure", "Map", "FixedMap":
+		return c.fromMapping(obj)
+	case "ValueTable":
+		return c.fromValueTable(obj)
+	case "base64Binary":
+		return c.fromBinaryData(obj)
+	}
+
+	s, err := c.conn.Call("XMLСтрока", obj)
+	if err != nil {
+		// no XML presentation, fall back to the plain one
+		return c.conn.Call("Строка", obj)
+	}
+	return s, nil
+}
+
+// typeName returns the XML type name of an object, XMLТип(ТипЗнч(obj)).ИмяТипа,
+// or an empty string when the type has no XML mapping
+func (c *Converter) typeName(obj Object) string {
+	typ, err := callObject(c.conn, "ТипЗнч", obj)
+	if err != nil {
+		return ""
+	}
+	defer typ.Release()
+
+	xmlType, err := callObject(c.conn, "XMLТип", typ)
+	if err != nil {
+		return ""
+	}
+	defer xmlType.Release()
+
+	name, _ := xmlType.Get("ИмяТипа")
+	s, _ := name.(string)
+	return s
+}
+
+func (c *Converter) fromArray(obj Object) (any, error) {
+	res := []any{}
+	err := obj.Each(func(item any) error {
+		val, err := c.FromOneC(item)
+		if err != nil {
+			return err
+		}
+		res = append(res, val)
+		return nil
+	})
+	return res, err
+}
+
+// fromMapping converts Структура and Соответствие, whose items are
+// КлючИЗначение. Keys that are not strings are converted and printed.
+func (c *Converter) fromMapping(obj Object) (any, error) {
+	res := map[string]any{}
+	err := obj.Each(func(item any) error {
+		pair, ok := item.(Object)
+		if !ok {
+			return fmt.Errorf("collection item is %T, not КлючИЗначение", item)
+		}
+		key, err := pair.Get("Ключ")
+		if err != nil {
+			return err
+		}
+		if key, err = c.FromOneC(key); err != nil {
+			return err
+		}
+		val, err := pair.Get("Значение")
+		if err != nil {
+			return err
+		}
+		if val, err = c.FromOneC(val); err != nil {
+			return err
+		}
+		res[fmt.Sprint(key)] = val
+		return nil
+	})
+	return res, err
+}
+
+func (c *Converter) fromValueTable(obj Object) (any, error) {
+	names, err := columnNames(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []map[string]any{}
+	err = obj.Each(func(item any) error {
+		row, ok := item.(Object)
+		if !ok {
+			return fmt.Errorf("table row is %T, not an object", item)
+		}
+		m := make(map[string]any, len(names))
+		for _, name := range names {
+			val, err := row.Get(name)
+			if err != nil {
+				return err
+			}
+			if m[name], err = c.FromOneC(val); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		res = append(res, m)
+		return nil
+	})
+	return res, err
+}
+
+// fromBinaryData reads ДвоичныеДанные through Base64Строка
+func (c *Converter) fromBinaryData(obj Object) (any, error) {
+	res, err := c.conn.Call("Base64Строка", obj)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := res.(string)
+	if !ok {
+		return nil, fmt.Errorf("Base64Строка returned %T", res)
+	}
+	// 1C breaks base64 into lines, the decoder skips line breaks
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// Decode stores a value converted from 1C into dst, a pointer to a struct,
+// slice, map or plain value. Keys of Структура match struct fields as
+// encoding/json matches them.
+func Decode(v any, dst any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	return nil
+}
+
+// intValue converts a numeric result of a 1C call into int
+func intValue(v any) (int, error) {
+	switch val := v.(type) {
+	case int:
+		return val, nil
+	case int8:
+		return int(val), nil
+	case int16:
+		return int(val), nil
+	case int32:
+		return int(val), nil
+	case int64:
+		return int(val), nil
+	case uint8:
+		return int(val), nil
+	case uint16:
+		return int(val), nil
+	case uint32:
+		return int(val), nil
+	case float64:
+		return int(val), nil
+	case Decimal:
+		return int(val.Float64()), nil
+	default:
+		return 0, fmt.Errorf("%T is not a number", v)
+	}
+}
@@ -0,0 +1,261 @@
+package gocom1c
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeCollection records what the converter puts into a 1C collection
+type fakeCollection struct {
+	FakeObject
+	typeName string
+	pairs    [][2]any
+	released bool
+}
+
+func (c *fakeCollection) Call(method string, args ...any) (any, error) {
+	switch method {
+	case "Вставить":
+		c.pairs = append(c.pairs, [2]any{args[0], args[1]})
+	case "Добавить":
+		c.pairs = append(c.pairs, [2]any{nil, args[0]})
+	default:
+		return nil, fmt.Errorf("fake collection: method %s not found", method)
+	}
+	return nil, nil
+}
+
+func (c *fakeCollection) Release() { c.released = true }
+
+// typed is a 1C object of an XML type, refs have their UUID as
+// the XML string
+func typed(xmlType string, props map[string]any, items ...any) *FakeObject {
+	if props == nil {
+		props = map[string]any{}
+	}
+	props["xmlType"] = xmlType
+	return &FakeObject{Props: props, Items: items}
+}
+
+// fakeConverterConn serves the global context methods Converter calls
+func fakeConverterConn() *FakeObject {
+	return &FakeObject{Methods: map[string]func(args ...any) (any, error){
+		"NewObject": func(args ...any) (any, error) {
+			return &fakeCollection{typeName: args[0].(string)}, nil
+		},
+		"Base64Значение": func(args ...any) (any, error) {
+			return typed("base64Binary", map[string]any{"base64": args[0]}), nil
+		},
+		"Base64Строка": func(args ...any) (any, error) {
+			return args[0].(*FakeObject).Get("base64")
+		},
+		"ТипЗнч": func(args ...any) (any, error) {
+			name, err := args[0].(*FakeObject).Get("xmlType")
+			if err != nil {
+				return nil, err
+			}
+			return &FakeObject{Props: map[string]any{"xmlType": name}}, nil
+		},
+		"XMLТип": func(args ...any) (any, error) {
+			name, _ := args[0].(*FakeObject).Get("xmlType")
+			return &FakeObject{Props: map[string]any{"ИмяТипа": name}}, nil
+		},
+		"XMLСтрока": func(args ...any) (any, error) {
+			return args[0].(*FakeObject).Get("xml")
+		},
+		"Строка": func(args ...any) (any, error) {
+			return args[0].(*FakeObject).Get("presentation")
+		},
+	}}
+}
+
+func TestConverterToOneCValues(t *testing.T) {
+	conv := NewConverter(fakeConverterConn())
+	defer conv.Release()
+
+	date := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+	var nilPtr *int
+	five := 5
+	type code string
+	type qty int16
+	tests := []struct {
+		in   any
+		want any
+	}{
+		{nil, nil},
+		{"Гвозди", "Гвозди"},
+		{true, true},
+		{42, 42},
+		{2.5, 2.5},
+		{date, date},
+		{Decimal("1.5"), Decimal("1.5")},
+		{json.Number("12.50"), Decimal("12.5")},
+		{nilPtr, nil},
+		{&five, 5},
+		{[]int(nil), nil},
+		{code("A1"), "A1"},
+		{qty(-3), int64(-3)},
+	}
+	for _, tt := range tests {
+		got, err := conv.ToOneC(tt.in)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ToOneC(%#v) = %#v, %v, want %#v", tt.in, got, err, tt.want)
+		}
+	}
+
+	if _, err := conv.ToOneC(make(chan int)); err == nil {
+		t.Error("ToOneC accepted a channel")
+	}
+	if _, err := conv.ToOneC(map[string]any{"f": func() {}}); err == nil {
+		t.Error("ToOneC accepted a func in a map")
+	}
+}
+
+func TestConverterToOneCObjects(t *testing.T) {
+	conv := NewConverter(fakeConverterConn())
+
+	type Base struct {
+		ID int
+	}
+	type doc struct {
+		Base
+		Number  string `json:"Номер"`
+		Comment string `json:",omitempty"`
+		Secret  string `json:"-"`
+		hidden  int
+		Items   []string
+	}
+
+	tests := []struct {
+		in       any
+		typeName string
+		pairs    [][2]any
+	}{
+		{[]any{1, "a"}, "Массив", [][2]any{{nil, 1}, {nil, "a"}}},
+		{[2]bool{true, false}, "Массив", [][2]any{{nil, true}, {nil, false}}},
+		{map[string]int{"Б": 2, "А": 1}, "Структура", [][2]any{{"А", 1}, {"Б", 2}}},
+		{map[string]int{"не ключ": 1}, "Соответствие", [][2]any{{"не ключ", 1}}},
+		{map[int]string{7: "семь"}, "Соответствие", [][2]any{{7, "семь"}}},
+		{doc{Base: Base{ID: 1}, Number: "0001", Secret: "x", hidden: 2}, "Структура",
+			[][2]any{{"ID", 1}, {"Номер", "0001"}, {"Items", nil}}},
+	}
+	for _, tt := range tests {
+		got, err := conv.ToOneC(tt.in)
+		if err != nil {
+			t.Errorf("ToOneC(%#v): %v", tt.in, err)
+			continue
+		}
+		coll, ok := got.(*fakeCollection)
+		if !ok || coll.typeName != tt.typeName || !reflect.DeepEqual(coll.pairs, tt.pairs) {
+			t.Errorf("ToOneC(%#v) = %#v, want %s %v", tt.in, got, tt.typeName, tt.pairs)
+		}
+	}
+
+	// nested values are converted too
+	got, err := conv.ToOneC(map[string]any{"Строки": []any{map[string]any{"Сумма": json.Number("1.0")}}})
+	if err != nil {
+		t.Fatalf("ToOneC: %v", err)
+	}
+	rows := got.(*fakeCollection).pairs[0][1].(*fakeCollection)
+	row := rows.pairs[0][1].(*fakeCollection)
+	if rows.typeName != "Массив" || row.typeName != "Структура" || row.pairs[0] != [2]any{"Сумма", Decimal("1")} {
+		t.Fatalf("nested = %v, %v", rows, row)
+	}
+
+	bin, err := conv.ToOneC([]byte("данные"))
+	if err != nil {
+		t.Fatalf("ToOneC([]byte): %v", err)
+	}
+	if b64, _ := bin.(*FakeObject).Get("base64"); b64 != base64.StdEncoding.EncodeToString([]byte("данные")) {
+		t.Errorf("binary data = %v", b64)
+	}
+
+	// the created objects live until Release
+	conv.Release()
+	if !got.(*fakeCollection).released || !rows.released || !row.released {
+		t.Error("Release left created objects")
+	}
+}
+
+func TestConverterFromOneC(t *testing.T) {
+	conv := NewConverter(fakeConverterConn())
+	defer conv.Release()
+
+	pair := func(k, v any) *FakeObject {
+		return &FakeObject{Props: map[string]any{"Ключ": k, "Значение": v}}
+	}
+	ref := typed("CatalogRef.Номенклатура", map[string]any{"xml": "5f3a1c2e-0000-11ee-8000-000c29f1a6b7"})
+	table := typed("ValueTable", map[string]any{"Колонки": &FakeObject{Methods: map[string]func(args ...any) (any, error){
+		"Количество": func(args ...any) (any, error) { return 2, nil },
+		"Получить": func(args ...any) (any, error) {
+			return &FakeObject{Props: map[string]any{"Имя": []string{"Товар", "Цена"}[args[0].(int)]}}, nil
+		},
+	}}},
+		&FakeObject{Props: map[string]any{"Товар": ref, "Цена": 10.5}},
+	)
+
+	tests := []struct {
+		in   any
+		want any
+	}{
+		{nil, nil},
+		{"строка", "строка"},
+		{true, true},
+		{int32(-7), Decimal("-7")},
+		{uint8(255), Decimal("255")},
+		{uint64(18446744073709551615), Decimal("18446744073709551615")},
+		{float32(0.5), Decimal("0.5")},
+		{0.1, Decimal("0.1")},
+		{Decimal("3.14"), Decimal("3.14")},
+		{ref, "5f3a1c2e-0000-11ee-8000-000c29f1a6b7"},
+		{typed("EnumRef.Пол", map[string]any{"presentation": "Мужской"}), "Мужской"},
+		{typed("Array", nil, 1, "a", typed("Array", nil)), []any{Decimal("1"), "a", []any{}}},
+		{typed("Structure", nil, pair("Сумма", 100), pair("Товар", ref)),
+			map[string]any{"Сумма": Decimal("100"), "Товар": "5f3a1c2e-0000-11ee-8000-000c29f1a6b7"}},
+		{typed("Map", nil, pair(1, "один")), map[string]any{"1": "один"}},
+		{table, []map[string]any{{"Товар": "5f3a1c2e-0000-11ee-8000-000c29f1a6b7", "Цена": Decimal("10.5")}}},
+		{typed("base64Binary", map[string]any{"base64": "0LTQsNC9\r\n0L3Ri9C1"}), []byte("данные")},
+	}
+	for _, tt := range tests {
+		got, err := conv.FromOneC(tt.in)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FromOneC(%v) = %#v, %v, want %#v", tt.in, got, err, tt.want)
+		}
+	}
+
+	if _, err := conv.FromOneC(typed("Structure", nil, "не пара")); err == nil {
+		t.Error("FromOneC accepted a structure item that is not КлючИЗначение")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var doc struct {
+		Number string  `json:"Номер"`
+		Sum    Decimal `json:"Сумма"`
+		Total  float64 `json:"Сумма2"`
+		Rows   []struct {
+			Item string `json:"Товар"`
+		} `json:"Строки"`
+	}
+	v := map[string]any{
+		"Номер":  "0001",
+		"Сумма":  Decimal("10.50"),
+		"Сумма2": Decimal("0.25"),
+		"Строки": []any{map[string]any{"Товар": "Гвозди"}},
+	}
+	if err := Decode(v, &doc); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if doc.Number != "0001" || doc.Sum != "10.5" || doc.Total != 0.25 || len(doc.Rows) != 1 || doc.Rows[0].Item != "Гвозди" {
+		t.Fatalf("Decode = %+v", doc)
+	}
+
+	var n int
+	if err := Decode("не число", &n); err == nil {
+		t.Error("Decode stored a string into int")
+	}
+}
@@ -0,0 +1,107 @@
+package gocom1c
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, as 1C Число. It holds the number
+// in its canonical text form, e.g. "-123.45".
+type Decimal string
+
+// ParseDecimal parses a decimal number in plain or exponent notation
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	r, ok := new(big.Rat).SetString(s)
+	// big.Rat also reads fractions and base prefixes
+	if !ok || strings.ContainsFunc(s, notDecimalRune) {
+		return "", fmt.Errorf("invalid decimal %q", s)
+	}
+	return DecimalFromRat(r), nil
+}
+
+func notDecimalRune(r rune) bool {
+	return !strings.ContainsRune("0123456789+-.eE", r)
+}
+
+// DecimalFromInt returns the decimal of an integer
+func DecimalFromInt(i int64) Decimal {
+	return Decimal(strconv.FormatInt(i, 10))
+}
+
+// DecimalFromFloat returns the shortest decimal that represents f
+func DecimalFromFloat(f float64) Decimal {
+	return canonicalDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// DecimalFromRat returns the decimal of r. Fractions that do not end
+// are cut at 27 digits, the precision of 1C numbers.
+func DecimalFromRat(r *big.Rat) Decimal {
+	return canonicalDecimal(r.FloatString(27))
+}
+
+// decimalFromParts builds a decimal of an unscaled integer and a scale,
+// the way OLE DECIMAL keeps it
+func decimalFromParts(unscaled *big.Int, scale int, neg bool) Decimal {
+	digits := unscaled.String()
+	if scale > 0 {
+		if len(digits) <= scale {
+			digits = strings.Repeat("0", scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	}
+	if neg {
+		digits = "-" + digits
+	}
+	return canonicalDecimal(digits)
+}
+
+// canonicalDecimal strips trailing fraction zeros and the sign of zero
+func canonicalDecimal(s string) Decimal {
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return Decimal(s)
+}
+
+// String returns the number in text form
+func (d Decimal) String() string {
+	if d == "" {
+		return "0"
+	}
+	return string(d)
+}
+
+// Float64 returns the nearest float64 value of the number
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Rat returns the exact value of the number
+func (d Decimal) Rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(d.String())
+	return r
+}
+
+// MarshalJSON writes the number as a JSON number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON reads the number from a JSON number or string
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
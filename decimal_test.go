@@ -0,0 +1,120 @@
+package gocom1c
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Decimal
+	}{
+		{"0", "0"},
+		{"-0", "0"},
+		{"-0.000", "0"},
+		{"123.4500", "123.45"},
+		{" -17.5 ", "-17.5"},
+		{"+3", "3"},
+		{"1e3", "1000"},
+		{"1.25E-3", "0.00125"},
+		{"79228162514264337593543950336", "79228162514264337593543950336"},
+		{"0.1234567890123456789012345678901", "0.123456789012345678901234568"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDecimal(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "abc", "1/3", "1,5", "0x10", "0b1", "1_000"} {
+		if got, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) = %q, want an error", in, got)
+		}
+	}
+}
+
+func TestDecimalFrom(t *testing.T) {
+	tests := []struct {
+		got, want Decimal
+	}{
+		{DecimalFromInt(-42), "-42"},
+		{DecimalFromInt(0), "0"},
+		{DecimalFromFloat(0.1), "0.1"},
+		{DecimalFromFloat(-2.50), "-2.5"},
+		{DecimalFromFloat(1e21), "1000000000000000000000"},
+		{DecimalFromRat(big.NewRat(-1, 8)), "-0.125"},
+		{DecimalFromRat(big.NewRat(1, 3)), Decimal("0." + strings.Repeat("3", 27))},
+		{DecimalFromRat(big.NewRat(2, 3)), Decimal("0." + strings.Repeat("6", 26) + "7")},
+	}
+	for i, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%d: got %q, want %q", i, tt.got, tt.want)
+		}
+	}
+}
+
+func TestDecimalFromParts(t *testing.T) {
+	tests := []struct {
+		unscaled int64
+		scale    int
+		neg      bool
+		want     Decimal
+	}{
+		{12345, 2, false, "123.45"},
+		{12345, 2, true, "-123.45"},
+		{5, 3, false, "0.005"},
+		{5, 3, true, "-0.005"},
+		{1200, 2, false, "12"},
+		{12345, 5, false, "0.12345"},
+		{0, 4, true, "0"},
+		{7, 0, true, "-7"},
+	}
+	for _, tt := range tests {
+		got := decimalFromParts(big.NewInt(tt.unscaled), tt.scale, tt.neg)
+		if got != tt.want {
+			t.Errorf("decimalFromParts(%d, %d, %v) = %q, want %q", tt.unscaled, tt.scale, tt.neg, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalValues(t *testing.T) {
+	var zero Decimal
+	if zero.String() != "0" || zero.Float64() != 0 || zero.Rat().Sign() != 0 {
+		t.Errorf("zero Decimal = %q, %v, %v", zero.String(), zero.Float64(), zero.Rat())
+	}
+
+	d := Decimal("-123.45")
+	if f := d.Float64(); f != -123.45 {
+		t.Errorf("Float64 = %v", f)
+	}
+	if r := d.Rat(); r.Cmp(big.NewRat(-12345, 100)) != 0 {
+		t.Errorf("Rat = %v", r)
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	data, err := json.Marshal(map[string]Decimal{"sum": "-0.125", "none": ""})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if got, want := string(data), `{"none":0,"sum":-0.125}`; got != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+
+	var v struct {
+		A, B, C Decimal
+	}
+	if err := json.Unmarshal([]byte(`{"A":12.50,"B":"-3","C":1e2}`), &v); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if v.A != "12.5" || v.B != "-3" || v.C != "100" {
+		t.Errorf("Unmarshal = %+v", v)
+	}
+	if err := json.Unmarshal([]byte(`{"A":"много"}`), &v); err == nil {
+		t.Error("Unmarshal accepted a word")
+	}
+}
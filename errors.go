@@ -0,0 +1,152 @@
+package gocom1c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrPoolClosed is returned by calls on a pool that has been closed.
+	ErrPoolClosed = errors.New("pool is shutdown")
+
+	// ErrAcquireTimeout is returned when no connection gets free
+	// within Config.WaitConnTimeout.
+	ErrAcquireTimeout = errors.New("timeout waiting for COM connection")
+
+	// ErrCommandTimeout is returned when a command runs longer than
+	// Config.CommandTimeout. The connection it ran on is quarantined.
+	ErrCommandTimeout = errors.New("command execution timeout")
+
+	// ErrConnBroken is wrapped by backends into errors that mean the 1C
+	// session is lost. The connection is discarded and reconnected.
+	ErrConnBroken = errors.New("connection to 1C is broken")
+
+	// ErrUnknownInfobase is returned by PoolManager for a name
+	// no pool has been added with.
+	ErrUnknownInfobase = errors.New("unknown infobase")
+
+	// ErrUserPoolsFull is returned by COMPool.AsUser when MaxUserPools
+	// sub-pools are open and all of them are in use.
+	ErrUserPoolsFull = errors.New("too many 1C user pools in use")
+
+	// ErrSessionClosed is returned by calls on a pinned session that has
+	// been committed, rolled back or expired, and by COMPool.PinnedSession
+	// for an unknown session ID.
+	ErrSessionClosed = errors.New("session is closed")
+
+	errPoolFull = errors.New("maximum pool size reached")
+)
+
+// Phases of opening a connection, see ConnectError
+const (
+	PhaseInit       = "init"       // COM initialization and connector creation
+	PhaseConnect    = "connect"    // connecting to the infobase
+	PhaseProcessing = "processing" // loading the command processing
+)
+
+// Phases of running a command, see CommandError
+const (
+	PhaseAcquire = "acquire" // waiting for a connection
+	PhaseExecute = "execute" // running the command in 1C
+	PhaseResult  = "result"  // converting the result
+)
+
+// ConnectError is returned when a connection to 1C can not be opened
+type ConnectError struct {
+	ConnID int
+	Phase  string
+	Err    error
+}
+
+func (e *ConnectError) Error() string {
+	return fmt.Sprintf("failed to initialize COM connection %d (%s): %v", e.ConnID, e.Phase, e.Err)
+}
+
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+// CommandError is returned when a command fails. ConnID is -1 when the
+// command failed before a connection was acquired.
+type CommandError struct {
+	ConnID  int
+	Command string
+	Phase   string
+	Err     error
+}
+
+func (e *CommandError) Error() string {
+	if e.ConnID < 0 {
+		return fmt.Sprintf("command %s (%s): %v", e.Command, e.Phase, e.Err)
+	}
+	return fmt.Sprintf("command %s on connection %d (%s): %v", e.Command, e.ConnID, e.Phase, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Error codes returned by ErrorCode
+const (
+	CodePoolClosed     = "pool_closed"
+	CodeAcquireTimeout = "acquire_timeout"
+	CodeCommandTimeout = "command_timeout"
+	CodeCanceled       = "canceled"
+	CodeConnBroken     = "conn_broken"
+	CodeConnectFailed  = "connect_failed"
+	CodeException      = "1c_exception"
+	CodeCommandFailed  = "command_failed"
+	CodeUnknownBase    = "unknown_infobase"
+	CodeSessionClosed  = "session_closed"
+	CodeUserPoolsFull  = "user_pools_full"
+	CodeUnknown        = "unknown"
+)
+
+// ErrorCode returns a stable code of an error returned by the pool,
+// for frontends to map onto their status codes
+func ErrorCode(err error) string {
+	var connectErr *ConnectError
+	var commandErr *CommandError
+	var oneCErr *OneCError
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrPoolClosed):
+		return CodePoolClosed
+	case errors.Is(err, ErrUnknownInfobase):
+		return CodeUnknownBase
+	case errors.Is(err, ErrSessionClosed):
+		return CodeSessionClosed
+	case errors.Is(err, ErrUserPoolsFull):
+		return CodeUserPoolsFull
+	case errors.Is(err, ErrAcquireTimeout):
+		return CodeAcquireTimeout
+	case errors.Is(err, ErrCommandTimeout), errors.Is(err, context.DeadlineExceeded):
+		return CodeCommandTimeout
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	case errors.Is(err, ErrConnBroken):
+		return CodeConnBroken
+	case errors.As(err, &connectErr):
+		return CodeConnectFailed
+	case errors.As(err, &oneCErr):
+		return CodeException
+	case errors.As(err, &commandErr):
+		return CodeCommandFailed
+	default:
+		return CodeUnknown
+	}
+}
+
+// IsRetryable reports whether a failed call may be repeated as is:
+// the command either has not reached 1C or its session was lost
+func IsRetryable(err error) bool {
+	switch ErrorCode(err) {
+	case CodeAcquireTimeout, CodeConnBroken, CodeConnectFailed, CodeUserPoolsFull:
+		return true
+	default:
+		return false
+	}
+}
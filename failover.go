@@ -0,0 +1,168 @@
+package gocom1c
+
+import (
+	"errors"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// endpoint is an infobase connection string the pool can connect to
+type endpoint struct {
+	cfg  *Config // pool config with the endpoint connection string
+	name string  // the connection string with passwords masked
+}
+
+// newEndpoints returns Config.Endpoints in order, or ConnectionString
+// when there are none
+func newEndpoints(cfg *Config) []endpoint {
+	conns := cfg.Endpoints
+	if len(conns) == 0 {
+		conns = []string{cfg.ConnectionString}
+	}
+
+	endpoints := make([]endpoint, len(conns))
+	for i, conn := range conns {
+		epCfg := *cfg
+		epCfg.ConnectionString = conn
+		endpoints[i] = endpoint{cfg: &epCfg, name: RedactConnectionString(conn)}
+	}
+	return endpoints
+}
+
+// startWorker starts the connection worker on the current endpoint. When
+// the infobase can not be connected, the next endpoints are tried in turn
+// and the first one connected becomes current.
+func (p *COMPool) startWorker(conn *COMConnection) error {
+	current := int(p.endpoint.Load())
+
+	var err error
+	for i := range p.endpoints {
+		ep := (current + i) % len(p.endpoints)
+
+		ready := make(chan error, 1)
+		conn.wg.Add(1)
+		go conn.worker(p.endpoints[ep].cfg, ready, p.logger)
+
+		if err = <-ready; err == nil {
+			conn.endpoint = ep
+			conn.endpointName = p.endpoints[ep].name
+			if ep != current {
+				p.switchEndpoint(current, ep, false)
+			}
+			return nil
+		}
+
+		// other phases fail the same way on any endpoint
+		var connectErr *ConnectError
+		if !errors.As(err, &connectErr) || connectErr.Phase != PhaseConnect {
+			return err
+		}
+		if len(p.endpoints) > 1 {
+			p.logger.Warnf("COM connection %d failed to connect to %s: %v", conn.id, p.endpoints[ep].name, err)
+		}
+	}
+	return err
+}
+
+// switchEndpoint makes the pool use endpoint to instead of from and
+// reports whether it did: nothing is done when another switch has already
+// moved the pool away from from. The switch counts as a failback when
+// failback is set and as a failover otherwise, even when a failover
+// wraps around to a preferred endpoint.
+func (p *COMPool) switchEndpoint(from int, to int, failback bool) bool {
+	if !p.endpoint.CompareAndSwap(int32(from), int32(to)) {
+		return false
+	}
+
+	p.poolMutex.Lock()
+	if failback {
+		p.stats.Failbacks++
+	} else {
+		p.stats.Failovers++
+	}
+	p.poolMutex.Unlock()
+
+	p.logger.Warnf("COM pool switched from %s to %s", p.endpoints[from].name, p.endpoints[to].name)
+	return true
+}
+
+// failbackLoop probes the preferred endpoints every FailbackInterval
+// while the pool works on a standby one
+func (p *COMPool) failbackLoop() {
+	ticker := time.NewTicker(p.cfg.FailbackInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.failback()
+		case <-p.shutdown:
+			return
+		}
+	}
+}
+
+// failback switches back to the first preferred endpoint that can be
+// connected again. Connections on the standby endpoints are replaced
+// on the recovered one: idle ones at once, busy ones after their
+// current calls.
+func (p *COMPool) failback() {
+	current := int(p.endpoint.Load())
+	for ep := range current {
+		if err := p.probeEndpoint(ep); err != nil {
+			p.logger.Debugf("COM pool endpoint %s is still down: %v", p.endpoints[ep].name, err)
+			continue
+		}
+
+		// the connections of a switch that came first are left to it
+		if p.switchEndpoint(current, ep, true) {
+			p.expireStandby(ep)
+		}
+		return
+	}
+}
+
+// expireStandby expires the connections on the endpoints after ep
+// and retires the idle ones
+func (p *COMPool) expireStandby(ep int) {
+	p.poolMutex.Lock()
+	for _, conn := range p.connections {
+		conn.mutex.Lock()
+		if conn.endpoint > ep {
+			conn.expires = time.Now()
+		}
+		conn.mutex.Unlock()
+	}
+	var due []*COMConnection
+	p.idle = slices.DeleteFunc(p.idle, func(conn *COMConnection) bool {
+		if conn.expired() {
+			due = append(due, conn)
+			return true
+		}
+		return false
+	})
+	p.poolMutex.Unlock()
+
+	for _, conn := range due {
+		p.retireConnection(conn)
+	}
+}
+
+// probeEndpoint opens and closes a session on the endpoint
+func (p *COMPool) probeEndpoint(ep int) error {
+	res := make(chan error, 1)
+	go func() {
+		// the session is bound to the thread, as on a worker
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		cfg := p.endpoints[ep].cfg
+		session, err := cfg.Backend.Open(cfg, p.logger)
+		if err == nil {
+			session.Close()
+		}
+		res <- err
+	}()
+	return <-res
+}
@@ -0,0 +1,193 @@
+package gocom1c
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+var testEndpoints = []string{
+	`Srvr="a";Ref="base";`,
+	`Srvr="b";Ref="base";`,
+	`Srvr="c";Ref="base";`,
+}
+
+func TestFailoverAndFailback(t *testing.T) {
+	b := &FakeBackend{}
+	b.SetDown(testEndpoints[0], true)
+	pool := newTestPool(t, b, Config{
+		Endpoints:        testEndpoints,
+		MinPoolSize:      1,
+		FailbackInterval: 10 * time.Millisecond,
+	})
+
+	if stats := pool.Stats(); stats.Failovers != 1 || stats.Failbacks != 0 {
+		t.Fatalf("failovers = %d, failbacks = %d, want 1, 0", stats.Failovers, stats.Failbacks)
+	}
+	if ep := pool.endpoint.Load(); ep != 1 {
+		t.Fatalf("endpoint = %d, want 1", ep)
+	}
+
+	b.SetDown(testEndpoints[0], false)
+	waitFor(t, "failback", func() bool { return pool.endpoint.Load() == 0 })
+	if stats := pool.Stats(); stats.Failovers != 1 || stats.Failbacks != 1 {
+		t.Fatalf("failovers = %d, failbacks = %d, want 1, 1", stats.Failovers, stats.Failbacks)
+	}
+}
+
+func TestFailoverWrapAround(t *testing.T) {
+	b := &FakeBackend{}
+	b.SetDown(testEndpoints[0], true)
+	b.SetDown(testEndpoints[1], true)
+	pool := newTestPool(t, b, Config{
+		Endpoints:        testEndpoints,
+		MinPoolSize:      1,
+		MaxPoolSize:      2,
+		FailbackInterval: time.Hour,
+	})
+	if ep := pool.endpoint.Load(); ep != 2 {
+		t.Fatalf("endpoint = %d, want 2", ep)
+	}
+
+	// the last endpoint fails, the next connection wraps around to the first
+	b.SetDown(testEndpoints[2], true)
+	b.SetDown(testEndpoints[0], false)
+	held, err := pool.GetConnection()
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	defer pool.ReleaseConnection(held)
+	conn, err := pool.GetConnection()
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	defer pool.ReleaseConnection(conn)
+
+	if conn.endpoint != 0 || pool.endpoint.Load() != 0 {
+		t.Fatalf("connection endpoint = %d, pool endpoint = %d, want 0", conn.endpoint, pool.endpoint.Load())
+	}
+	if stats := pool.Stats(); stats.Failovers != 2 || stats.Failbacks != 0 {
+		t.Fatalf("failovers = %d, failbacks = %d, want 2, 0", stats.Failovers, stats.Failbacks)
+	}
+}
+
+// connEndpoints returns the endpoints of the pool connections
+func connEndpoints(pool *COMPool) []int {
+	pool.poolMutex.RLock()
+	defer pool.poolMutex.RUnlock()
+
+	eps := make([]int, 0, len(pool.connections))
+	for _, conn := range pool.connections {
+		eps = append(eps, conn.endpoint)
+	}
+	return eps
+}
+
+func TestFailbackRetiresIdle(t *testing.T) {
+	b := &FakeBackend{}
+	b.SetDown(testEndpoints[0], true)
+	pool := newTestPool(t, b, Config{
+		Endpoints:        testEndpoints,
+		MinPoolSize:      2,
+		MaxPoolSize:      2,
+		FailbackInterval: time.Hour,
+	})
+	if eps := connEndpoints(pool); !slices.Equal(eps, []int{1, 1}) {
+		t.Fatalf("endpoints = %v, want the standby one", eps)
+	}
+
+	// idle connections move without being borrowed
+	b.SetDown(testEndpoints[0], false)
+	pool.failback()
+	waitFor(t, "connections on the recovered endpoint", func() bool {
+		return slices.Equal(connEndpoints(pool), []int{0, 0}) && pool.Stats().Idle == 2
+	})
+	if stats := pool.Stats(); stats.Retired != 2 || stats.Failbacks != 1 {
+		t.Fatalf("stats = %+v, want 2 retired on failback", stats)
+	}
+}
+
+func TestFailbackBusyRetiredOnRelease(t *testing.T) {
+	b := &FakeBackend{}
+	b.SetDown(testEndpoints[0], true)
+	pool := newTestPool(t, b, Config{
+		Endpoints:        testEndpoints,
+		MinPoolSize:      1,
+		MaxPoolSize:      1,
+		FailbackInterval: time.Hour,
+	})
+
+	conn, err := pool.GetConnection()
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	b.SetDown(testEndpoints[0], false)
+	pool.failback()
+	if stats := pool.Stats(); stats.Retired != 0 || stats.Active != 1 {
+		t.Fatalf("stats = %+v, want the busy connection kept", stats)
+	}
+
+	pool.ReleaseConnection(conn)
+	waitFor(t, "connection on the recovered endpoint", func() bool {
+		return slices.Equal(connEndpoints(pool), []int{0}) && pool.Stats().Idle == 1
+	})
+}
+
+// switchingBackend makes another switch while the first endpoint is probed
+type switchingBackend struct {
+	*FakeBackend
+	probed func()
+}
+
+func (b *switchingBackend) Open(cfg *Config, logger Logger) (Session, error) {
+	if cfg.ConnectionString == testEndpoints[0] && b.probed != nil {
+		b.probed()
+	}
+	return b.FakeBackend.Open(cfg, logger)
+}
+
+func TestFailbackLosesSwitch(t *testing.T) {
+	fake := &FakeBackend{}
+	fake.SetDown(testEndpoints[0], true)
+	fake.SetDown(testEndpoints[1], true)
+	b := &switchingBackend{FakeBackend: fake}
+
+	pool, err := NewCOMPool(&Config{
+		Backend:          b,
+		Endpoints:        testEndpoints,
+		MinPoolSize:      1,
+		FailbackInterval: time.Hour,
+		TempDir:          t.TempDir(),
+	}, testLogger{t})
+	if err != nil {
+		t.Fatalf("NewCOMPool: %v", err)
+	}
+	defer pool.Close()
+	if ep := pool.endpoint.Load(); ep != 2 {
+		t.Fatalf("endpoint = %d, want 2", ep)
+	}
+
+	fake.SetDown(testEndpoints[0], false)
+	b.probed = func() {
+		b.probed = nil
+		if !pool.switchEndpoint(2, 1, false) {
+			t.Error("switchEndpoint(2, 1) did not switch")
+		}
+	}
+	pool.failback()
+
+	if ep := pool.endpoint.Load(); ep != 1 {
+		t.Fatalf("endpoint = %d, want the one of the switch that came first", ep)
+	}
+	stats := pool.Stats()
+	if stats.Failbacks != 0 || stats.Retired != 0 || stats.Idle != 1 {
+		t.Fatalf("stats = %+v, want no failback and the connection kept", stats)
+	}
+	pool.poolMutex.RLock()
+	defer pool.poolMutex.RUnlock()
+	for _, conn := range pool.connections {
+		if conn.expired() {
+			t.Fatalf("connection %d expired by a failback that did not switch", conn.id)
+		}
+	}
+}
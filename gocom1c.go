@@ -2,27 +2,60 @@
 package gocom1c
 
 import (
+	"container/list"
+	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/go-ole/go-ole/oleutil"
 )
 
 // COMPool manages a pool of COM connections
 type COMPool struct {
 	cfg         *Config
 	connections []*COMConnection
-	freeConn    chan *COMConnection
-	createMutex sync.Mutex
+	quarantined []*COMConnection // connections stuck in an abandoned call
+	idle        []*COMConnection // free connections, least recently used first
+	waiters     list.List        // *waiter queued in GetConnection, oldest first
 	closeOnce   sync.Once
 	shutdown    chan struct{}
+	ready       chan struct{} // closed once MinPoolSize is first reached
+	readyOnce   sync.Once
+	refill      chan struct{} // wakes refillLoop
 	logger      Logger
 	nextID      int
+	opening     sync.WaitGroup // openConnection calls, Close waits for them
+	stopping    sync.WaitGroup // discarded connections, Close waits for them
 	activeCount int
+	pending     int // connections being created
+	stats       PoolStats
+	generation  atomic.Uint64 // incremented by Reload
+	version     string        // processing version seen by checkVersion
+	tempFiles   *TempFiles
+	endpoints   []endpoint
+	endpoint    atomic.Int32 // index of the endpoint new connections use
+	parent      *COMPool     // the pool of a sub-pool, see AsUser
+	users       map[string]*userPool
+	draining    []*COMPool     // sub-pools replaced after a password change
+	creating    int            // sub-pools being created
+	closing     sync.WaitGroup // sub-pools closed in background, see closeUserPool
+	usersMutex  sync.Mutex
+	sessions    map[string]*PinnedSession // by ID, see BeginSession
+	sessMutex   sync.Mutex
 	poolMutex   sync.RWMutex
 }
 
+// waiter is a caller queued for a free connection
+type waiter struct {
+	ch     chan *COMConnection // buffered, receives one connection or nil for a free slot
+	elem   *list.Element
+	served bool // ch has been sent to, set under poolMutex
+}
+
 // Result represents the result of a COM operation
 type Result struct {
 	Value any
@@ -32,30 +65,69 @@ type Result struct {
 // NewCOMPool creates a new COM connection pool
 func NewCOMPool(cfg *Config, logger Logger) (*COMPool, error) {
 	cfg.SetDefaults()
+	cfg.tempFiles = NewTempFiles(cfg.TempDir, logger)
+	cfg.tempFiles.start(cfg.TempFileMaxAge)
 
+	return newCOMPool(cfg, logger, nil)
+}
+
+// newCOMPool creates a pool with a config that has the defaults set.
+// A sub-pool shares the temporary files of its parent.
+func newCOMPool(cfg *Config, logger Logger, parent *COMPool) (*COMPool, error) {
 	pool := &COMPool{
 		cfg:         cfg,
 		connections: make([]*COMConnection, 0, cfg.MaxPoolSize),
-		freeConn:    make(chan *COMConnection, cfg.MaxPoolSize),
+		idle:        make([]*COMConnection, 0, cfg.MaxPoolSize),
 		shutdown:    make(chan struct{}),
+		ready:       make(chan struct{}),
+		refill:      make(chan struct{}, 1),
 		logger:      logger,
+		tempFiles:   cfg.tempFiles,
+		endpoints:   newEndpoints(cfg),
+		parent:      parent,
 	}
 
-	// Initialize minimum connections
-	if err := pool.InitConnections(); err != nil {
-		pool.Close()
-		return nil, fmt.Errorf("failed to create initial connection: %w", err)
+	if cfg.LazyStart {
+		// connections are created by refillLoop in background
+		logger.Infof("COM pool lazy start, filling up to %d connections in background", cfg.MinPoolSize)
+	} else {
+		// Initialize minimum connections
+		if err := pool.InitConnections(); err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to create initial connection: %w", err)
+		}
+		pool.markReady()
 	}
+	go pool.refillLoop()
 
 	// Start cleanup goroutine
 	go pool.cleanupIdleConnections()
 
+	if cfg.HealthCheckInterval > 0 {
+		go pool.healthCheckLoop()
+	}
+
+	if cfg.ReloadCheckInterval > 0 {
+		go pool.versionCheckLoop()
+	}
+
+	if len(pool.endpoints) > 1 {
+		go pool.failbackLoop()
+	}
+
 	return pool, nil
 }
 
 // Execute runs a function on a COM connection
 func (p *COMPool) Execute(fn func(conn *COMConnection) (any, error)) (any, error) {
-	conn, err := p.GetConnection()
+	return p.ExecuteContext(context.Background(), fn)
+}
+
+// ExecuteContext runs a function on a COM connection.
+// ctx limits the wait for a free connection, fn is expected to pass
+// it on to the connection calls.
+func (p *COMPool) ExecuteContext(ctx context.Context, fn func(conn *COMConnection) (any, error)) (any, error) {
+	conn, err := p.GetConnectionContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -64,20 +136,45 @@ func (p *COMPool) Execute(fn func(conn *COMConnection) (any, error)) (any, error
 	return fn(conn)
 }
 
+// Do runs fn with the session of a pooled connection, on its worker
+// goroutine, see COMConnection.Do
+func (p *COMPool) Do(ctx context.Context, fn func(s Session) error) error {
+	conn, err := p.GetConnectionContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.ReleaseConnection(conn)
+
+	return conn.Do(ctx, fn)
+}
+
 // ExecuteCommand executes a command on 1C COM object
 func (p *COMPool) ExecuteCommand(command string, params string) ([]byte, error) {
-	result, err := p.Execute(func(conn *COMConnection) (any, error) {
-		return conn.ExecuteCommand(command, params)
-	})
+	return p.ExecuteCommandContext(context.Background(), command, params)
+}
+
+// ExecuteCommandContext executes a command on 1C COM object.
+// If ctx is done while the command is running, the call is abandoned
+// and its connection is discarded instead of being reused.
+// Idempotent commands are retried once when the connection breaks.
+func (p *COMPool) ExecuteCommandContext(ctx context.Context, command string, params string) ([]byte, error) {
+	exec := func(conn *COMConnection) (any, error) {
+		return conn.ExecuteCommandContext(ctx, command, params)
+	}
+	result, err := p.ExecuteContext(ctx, exec)
+	if err != nil && errors.Is(err, ErrConnBroken) && p.cfg.IsIdempotent(command) {
+		p.logger.Warnf("Retrying idempotent command %s after broken connection: %v", command, err)
+		result, err = p.ExecuteContext(ctx, exec)
+	}
 	if err != nil {
+		var cmdErr *CommandError
+		if !errors.As(err, &cmdErr) {
+			err = &CommandError{ConnID: -1, Command: command, Phase: PhaseAcquire, Err: err}
+		}
 		return []byte{}, err
 	}
 
-	str, ok := result.(string)
-	if !ok {
-		return []byte{}, fmt.Errorf("result can not be converted to string")
-	}
-	return []byte(str), nil
+	return []byte(result.(string)), nil
 }
 
 func (p *COMPool) InitConnections() error {
@@ -96,10 +193,10 @@ func (p *COMPool) ConnStatuses() map[int]map[string]any {
 
 	stat := make(map[int]map[string]any)
 	for _, conn := range p.connections {
-		stat[conn.id] = map[string]any{
-			"useCount": conn.GetUseCount(),
-			"lastUsed": conn.GetLastUsed(),
-		}
+		stat[conn.id] = conn.status()
+	}
+	for _, conn := range p.quarantined {
+		stat[conn.id] = conn.status()
 	}
 	return stat
 }
@@ -114,204 +211,519 @@ func (p *COMPool) ActiveCount() int {
 // CloseConnections closes all connections
 func (p *COMPool) CloseConnections() {
 	p.poolMutex.Lock()
-	defer p.poolMutex.Unlock()
-
-	for _, conn := range p.connections {
-		p.closeConnection(conn)
-	}
+	conns := p.connections
 	p.connections = nil
+	p.idle = nil
 	p.activeCount = 0
+	p.poolMutex.Unlock()
+
+	// workers are stopped without holding poolMutex,
+	// so that woken waiters can leave the queue meanwhile
+	for _, conn := range conns {
+		p.stopWorker(conn)
+		p.logger.Infof("Closed COM connection %d", conn.id)
+	}
 }
 
 // Close shuts down the pool and all connections
 func (p *COMPool) Close() error {
 	p.closeOnce.Do(func() {
 		close(p.shutdown)
+		p.closeSessions()
+		p.closeUserPools()
+
+		// no slot is reserved and nothing is discarded in background once
+		// the lock is taken after shutdown, the ones under way are waited for
+		p.poolMutex.Lock()
+		p.poolMutex.Unlock()
+		p.opening.Wait()
+
 		p.CloseConnections()
+		p.stopping.Wait()
+		if p.parent == nil {
+			p.tempFiles.Close()
+		}
 	})
 
 	return nil
 }
 
+// cleanup evicts connections idle longer than IdleTimeout, least recently
+// used first, while the pool is above MinPoolSize
 func (p *COMPool) cleanup() {
 	p.poolMutex.Lock()
-	defer p.poolMutex.Unlock()
-
-	if p.activeCount <= p.cfg.MinPoolSize {
-		return
-	}
-
 	now := time.Now()
-	for _, conn := range p.connections {
-		if p.activeCount <= p.cfg.MinPoolSize {
+	var evicted []*COMConnection
+	for len(p.idle) > 0 && p.activeCount > p.cfg.MinPoolSize {
+		conn := p.idle[0]
+		if now.Sub(conn.GetLastUsed()) <= p.cfg.IdleTimeout {
+			// the rest of the list was used more recently
 			break
 		}
+		p.removeConnection(conn)
+		evicted = append(evicted, conn)
+	}
+	p.stats.IdleEvicted += int64(len(evicted))
+	remaining := p.activeCount
+	p.poolMutex.Unlock()
+
+	for _, conn := range evicted {
+		p.logger.Infof("Evicting COM connection %d idle for %v, remaining: %d",
+			conn.id, now.Sub(conn.GetLastUsed()).Round(time.Second), remaining)
+		p.stopWorker(conn)
+	}
+}
+
+// GetConnection acquires a COM connection from the pool
+func (p *COMPool) GetConnection() (*COMConnection, error) {
+	return p.GetConnectionContext(context.Background())
+}
+
+// GetConnectionContext acquires a COM connection from the pool.
+// A free connection is handed out at once. Otherwise a new connection is
+// created for the caller while the pool is under MaxPoolSize, or else the
+// caller is queued and served in FIFO order as connections are released.
+// The total wait is limited by WaitConnTimeout and ctx.
+func (p *COMPool) GetConnectionContext(ctx context.Context) (*COMConnection, error) {
+	deadline := time.Now().Add(p.cfg.WaitConnTimeout)
+
+	for {
+		// the caller may be gone after a connection failed its checks
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
-		conn.mutex.RLock()
-		idle := !conn.busy && now.Sub(conn.lastUsed) > p.cfg.IdleTimeout
-		conn.mutex.RUnlock()
-
-		if idle {
-			// Try to remove from freeConn channel
-			select {
-			case c := <-p.freeConn:
-				if c.id == conn.id {
-					p.closeConnection(conn)
-				} else {
-					// Put it back
-					p.freeConn <- c
-				}
-			default:
-				// No free connections in channel
+		conn, w, err := p.acquire()
+		if err != nil {
+			return nil, err
+		}
+		if w != nil {
+			conn, err = p.wait(ctx, w, deadline)
+			if err != nil {
+				return nil, err
+			}
+			if conn == nil {
+				// a slot got free, try to open a connection in it
+				continue
 			}
 		}
+
+		// an expired or unhealthy connection is replaced, try again
+		if p.prepareConnection(ctx, conn) {
+			return conn, nil
+		}
 	}
 }
 
-// Add cleanup method to COMConnection
-func (c *COMConnection) cleanup() {
-	if c.commandExec != nil {
-		c.commandExec.Clear()
-		c.commandExec = nil
-	}
-	if c.commandExecParent != nil {
-		c.commandExecParent.Clear()
-		c.commandExecParent = nil
+// acquire takes a free connection, or creates one when the pool is under
+// MaxPoolSize, or else queues a waiter
+func (p *COMPool) acquire() (*COMConnection, *waiter, error) {
+	p.poolMutex.Lock()
+
+	select {
+	case <-p.shutdown:
+		p.poolMutex.Unlock()
+		return nil, nil, ErrPoolClosed
+	default:
 	}
-	if c.v8 != nil {
-		c.v8.Clear()
-		c.v8 = nil
+
+	// the most recently used connection is handed out,
+	// so that the rest can age out
+	if n := len(p.idle); n > 0 {
+		conn := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.poolMutex.Unlock()
+		return conn, nil, nil
 	}
-}
 
-// ExecuteCommand executes a command on this COM connection
-func (c *COMConnection) ExecuteCommand(command string, params string) (string, error) {
-	resultChan := make(chan Result, 1)
+	if p.hasRoom() {
+		p.pending++
+		p.opening.Add(1)
+		p.poolMutex.Unlock()
 
-	c.commands <- func() {
-		res, err := oleutil.CallMethod(c.commandExec.ToIDispatch(), "ExecuteCommand", command, params)
+		conn, err := p.openConnection()
 		if err != nil {
-			resultChan <- Result{Error: err}
-			return
+			p.wakeWaiter()
+			return nil, nil, fmt.Errorf("failed to create new connection: %w", err)
 		}
-		defer res.Clear()
-
-		// Convert result to string
-		val := res.Value()
-		var strVal string
-		switch v := val.(type) {
-		case string:
-			strVal = v
-		case fmt.Stringer:
-			strVal = v.String()
-		default:
-			strVal = fmt.Sprintf("%v", v)
-		}
-
-		resultChan <- Result{Value: strVal}
+		return conn, nil, nil
 	}
 
-	result := <-resultChan
-	if result.Error != nil {
-		return "", result.Error
-	}
+	w := &waiter{ch: make(chan *COMConnection, 1)}
+	w.elem = p.waiters.PushBack(w)
+	p.poolMutex.Unlock()
 
-	return result.Value.(string), nil
+	return nil, w, nil
 }
 
-// GetConnection acquires a COM connection from the pool
-func (p *COMPool) GetConnection() (*COMConnection, error) {
+// wakeWaiter hands a free slot to the oldest waiter, which tries to open
+// a connection in it. Without it, waiters queued behind a failed open
+// would sit until WaitConnTimeout with the slot free.
+func (p *COMPool) wakeWaiter() {
+	p.poolMutex.Lock()
+	defer p.poolMutex.Unlock()
+
+	if front := p.waiters.Front(); front != nil && p.hasRoom() {
+		w := p.waiters.Remove(front).(*waiter)
+		w.served = true
+		w.ch <- nil
+	}
+}
+
+// wait waits for a connection to be handed to the waiter, nil when it is
+// woken to open one
+func (p *COMPool) wait(ctx context.Context, w *waiter, deadline time.Time) (*COMConnection, error) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	var err error
 	select {
-	case conn := <-p.freeConn:
-		conn.mutex.Lock()
-		conn.busy = true
-		conn.lastUsed = time.Now()
-		conn.useCount++
-		conn.mutex.Unlock()
-		p.logger.Debugf("Reusing connection %d", conn.id)
+	case conn := <-w.ch:
 		return conn, nil
-	case <-time.After(p.cfg.WaitConnTimeout):
-		// Try to create a new connection if under max pool size
-		p.poolMutex.RLock()
-		canCreate := p.activeCount < p.cfg.MaxPoolSize
-		p.poolMutex.RUnlock()
-
-		if canCreate {
-			if err := p.createConnection(); err != nil {
-				return nil, fmt.Errorf("failed to create new connection: %w", err)
-			}
-			return p.GetConnection()
-		}
-		return nil, fmt.Errorf("timeout waiting for COM connection")
+	case <-timer.C:
+		err = ErrAcquireTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
 	case <-p.shutdown:
-		return nil, fmt.Errorf("pool is shutdown")
+		err = ErrPoolClosed
+	}
+
+	p.poolMutex.Lock()
+	served := w.served
+	if !served {
+		p.waiters.Remove(w.elem)
+	}
+	p.poolMutex.Unlock()
+
+	if served {
+		// a connection or a free slot was handed over meanwhile, pass it on
+		if conn := <-w.ch; conn != nil {
+			p.putIdle(conn)
+		} else {
+			p.wakeWaiter()
+		}
+	}
+	return nil, err
+}
+
+// prepareConnection marks an acquired connection busy. An expired or
+// unhealthy connection is retired instead and false is returned.
+func (p *COMPool) prepareConnection(ctx context.Context, conn *COMConnection) bool {
+	if conn.expired() {
+		p.retireConnection(conn)
+		return false
+	}
+	if !p.validateOnBorrow(ctx, conn) {
+		return false
 	}
+	if p.outdated(conn) && !p.reloadConnection(ctx, conn) {
+		return false
+	}
+
+	conn.mutex.Lock()
+	conn.busy = true
+	conn.lastUsed = time.Now()
+	conn.useCount++
+	conn.mutex.Unlock()
+	p.logger.Debugf("Reusing connection %d", conn.id)
+
+	return true
+}
+
+// putIdle hands a free connection to the oldest waiter, or puts it
+// to the idle list, which is kept ordered by last use
+func (p *COMPool) putIdle(conn *COMConnection) {
+	p.poolMutex.Lock()
+	defer p.poolMutex.Unlock()
+
+	if front := p.waiters.Front(); front != nil {
+		w := p.waiters.Remove(front).(*waiter)
+		w.served = true
+		w.ch <- conn
+		return
+	}
+
+	lastUsed := conn.GetLastUsed()
+	i := sort.Search(len(p.idle), func(i int) bool {
+		return p.idle[i].GetLastUsed().After(lastUsed)
+	})
+	p.idle = slices.Insert(p.idle, i, conn)
+}
+
+// hasWaiters reports whether callers are queued for a connection
+func (p *COMPool) hasWaiters() bool {
+	p.poolMutex.RLock()
+	defer p.poolMutex.RUnlock()
+	return p.waiters.Len() > 0
 }
 
 // ReleaseConnection returns a connection to the pool
 func (p *COMPool) ReleaseConnection(conn *COMConnection) {
+	p.releaseConnection(conn, true)
+}
+
+// releaseConnection returns a connection to the pool, used is false
+// for pool own calls such as health probes that are not client activity
+func (p *COMPool) releaseConnection(conn *COMConnection, used bool) {
 	conn.mutex.Lock()
 	conn.busy = false
-	conn.lastUsed = time.Now()
+	if used {
+		conn.lastUsed = time.Now()
+	}
+	tainted := conn.tainted
+	broken := conn.broken
 	conn.mutex.Unlock()
 
-	select {
-	case p.freeConn <- conn:
-		p.logger.Debugf("Released connection %d back to pool", conn.id)
-	default:
-		// Pool is full, close this connection
-		p.logger.Debugf("Pool full, closing connection %d", conn.id)
-		p.closeConnection(conn)
+	if tainted {
+		p.quarantineConnection(conn)
+		return
 	}
+	if broken {
+		p.logger.Warnf("COM connection %d is broken, reconnecting", conn.id)
+		p.poolMutex.Lock()
+		p.stats.Broken++
+		p.poolMutex.Unlock()
+		p.discardConnection(conn)
+		go p.replaceConnection()
+		return
+	}
+	if conn.expired() {
+		p.retireConnection(conn)
+		return
+	}
+
+	p.putIdle(conn)
+	p.logger.Debugf("Released connection %d back to pool", conn.id)
 }
 
-// createConnection creates a new COM connection
+// createConnection creates a new COM connection and puts it to the pool
 func (p *COMPool) createConnection() error {
-	p.createMutex.Lock()
-	defer p.createMutex.Unlock()
-
 	p.poolMutex.Lock()
-	defer p.poolMutex.Unlock()
+	select {
+	case <-p.shutdown:
+		p.poolMutex.Unlock()
+		return ErrPoolClosed
+	default:
+	}
+	if !p.hasRoom() {
+		p.poolMutex.Unlock()
+		return errPoolFull
+	}
+	p.pending++
+	p.opening.Add(1)
+	p.poolMutex.Unlock()
 
-	if p.activeCount >= p.cfg.MaxPoolSize {
-		return fmt.Errorf("maximum pool size reached")
+	conn, err := p.openConnection()
+	if err != nil {
+		return err
 	}
+	p.putIdle(conn)
 
+	return nil
+}
+
+// hasRoom reports whether the pool may open one more connection. Quarantined
+// connections over MaxQuarantined take up MaxPoolSize, so that a hanging 1C
+// does not get ever more sessions. The caller holds poolMutex.
+func (p *COMPool) hasRoom() bool {
+	overflow := max(0, len(p.quarantined)-max(0, p.cfg.MaxQuarantined))
+	return p.activeCount+p.pending+overflow < p.cfg.MaxPoolSize
+}
+
+// openConnection starts a connection worker in a slot reserved
+// by incrementing p.pending and p.opening while the pool is open.
+// A connection opened after Close is closed again.
+func (p *COMPool) openConnection() (*COMConnection, error) {
+	defer p.opening.Done()
+
+	p.poolMutex.Lock()
+	now := time.Now()
 	conn := &COMConnection{
 		id:       p.nextID,
 		quit:     make(chan struct{}),
 		commands: make(chan func(), 100),
-		lastUsed: time.Now(),
+		timeout:  p.cfg.CommandTimeout,
+		created:  now,
+		maxUses:  p.cfg.MaxConnUses,
+		lastUsed: now,
 		busy:     false,
+		// the processing is loaded after a Reload that happens now
+		generation: p.generation.Load(),
+	}
+	if p.cfg.MaxConnLifetime > 0 {
+		conn.expires = now.Add(p.cfg.MaxConnLifetime - jitter(p.cfg.ConnLifetimeJitter))
 	}
 	p.nextID++
+	p.poolMutex.Unlock()
+
+	// Start COM worker goroutine and wait for initialization
+	err := p.startWorker(conn)
 
-	// Start COM worker goroutine
-	ready := make(chan error, 1)
-	go conn.comWorker(p.cfg, ready, p.logger)
+	p.poolMutex.Lock()
+	p.pending--
+	p.requestRefill()
+	if err != nil {
+		p.poolMutex.Unlock()
+		phase := PhaseConnect
+		var connectErr *ConnectError
+		if errors.As(err, &connectErr) {
+			phase, err = connectErr.Phase, connectErr.Err
+		}
+		return nil, &ConnectError{ConnID: conn.id, Phase: phase, Err: err}
+	}
 
-	// Wait for initialization
-	if err := <-ready; err != nil {
-		return fmt.Errorf("failed to initialize COM connection %d: %w", conn.id, err)
+	select {
+	case <-p.shutdown:
+		// CloseConnections does not see the connection, it is closed here
+		p.poolMutex.Unlock()
+		p.stopWorker(conn)
+		p.logger.Infof("Closed COM connection %d opened after the pool was closed", conn.id)
+		return nil, ErrPoolClosed
+	default:
 	}
 
 	p.connections = append(p.connections, conn)
 	p.activeCount++
+	p.stats.Created++
+	active := p.activeCount
+	p.poolMutex.Unlock()
 
-	// Add to free connections pool
+	p.logger.Infof("Created COM connection %d, total active: %d", conn.id, active)
+	return conn, nil
+}
+
+// discardConnection removes a connection from the pool and stops
+// its worker in background, as the worker may still be busy
+func (p *COMPool) discardConnection(conn *COMConnection) {
+	p.poolMutex.Lock()
+	if p.removeConnection(conn) {
+		p.logger.Infof("Closed COM connection %d, remaining: %d", conn.id, p.activeCount)
+	}
 	select {
-	case p.freeConn <- conn:
-		p.logger.Infof("Created COM connection %d, total active: %d", conn.id, p.activeCount)
+	case <-p.shutdown:
+		// Close is not waiting for it any more
+		p.poolMutex.Unlock()
+		p.stopWorker(conn)
+		return
 	default:
-		// Should not happen since we just created it
+		p.stopping.Add(1)
 	}
+	p.poolMutex.Unlock()
 
-	return nil
+	go func() {
+		defer p.stopping.Done()
+		p.stopWorker(conn)
+	}()
 }
 
-// closeConnection closes a specific connection
-func (p *COMPool) closeConnection(conn *COMConnection) {
-	close(conn.quit)
+// quarantineConnection takes a connection with an abandoned call out of
+// the pool capacity and spawns a replacement, unless MaxQuarantined
+// connections are quarantined already. The connection stays listed
+// in ConnStatuses until its worker returns from the call and exits.
+// A replacement refused then is created once it exits, if callers wait.
+func (p *COMPool) quarantineConnection(conn *COMConnection) {
+	conn.mutex.Lock()
+	conn.quarantinedAt = time.Now()
+	conn.mutex.Unlock()
+
+	p.poolMutex.Lock()
+	p.removeConnection(conn)
+	p.quarantined = append(p.quarantined, conn)
+	p.stats.TimedOut++
+	remaining := p.activeCount
+	p.poolMutex.Unlock()
+
+	p.logger.Warnf("COM connection %d quarantined: abandoned call still running, remaining: %d", conn.id, remaining)
+
+	conn.quitOnce.Do(func() {
+		close(conn.quit)
+	})
+
+	go func() {
+		conn.wg.Wait()
+
+		p.poolMutex.Lock()
+		for i, c := range p.quarantined {
+			if c.id == conn.id {
+				p.quarantined = append(p.quarantined[:i], p.quarantined[i+1:]...)
+				break
+			}
+		}
+		p.poolMutex.Unlock()
+
+		p.logger.Infof("Quarantined COM connection %d released", conn.id)
+		if p.hasWaiters() {
+			p.replaceConnection()
+		}
+	}()
+
+	go p.replaceConnection()
+}
+
+// retireConnection gracefully closes a connection that has outlived its
+// lifetime or use count and schedules a replacement after a random delay,
+// so that connections expiring together do not reconnect at once
+func (p *COMPool) retireConnection(conn *COMConnection) {
+	p.logger.Infof("COM connection %d retired after %d uses, age %v",
+		conn.id, conn.GetUseCount(), time.Since(conn.created).Round(time.Second))
+	p.poolMutex.Lock()
+	p.stats.Retired++
+	p.poolMutex.Unlock()
+	p.discardConnection(conn)
+
+	// callers waiting for a connection get the replacement at once
+	delay := jitter(p.cfg.ConnLifetimeJitter)
+	if p.hasWaiters() {
+		delay = 0
+	}
+	go func() {
+		select {
+		case <-time.After(delay):
+			p.replaceConnection()
+		case <-p.shutdown:
+		}
+	}()
+}
+
+// jitter returns a random duration in [0, d)
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
+
+// replaceConnection creates a connection in place of a removed one,
+// retrying with exponential backoff while 1C is unreachable
+func (p *COMPool) replaceConnection() {
+	delay := p.cfg.ReconnectMinDelay
+	for {
+		select {
+		case <-p.shutdown:
+			return
+		default:
+		}
+
+		err := p.createConnection()
+		if err == nil || errors.Is(err, errPoolFull) || errors.Is(err, ErrPoolClosed) {
+			return
+		}
+		p.logger.Errorf("Failed to create replacement connection, retrying in %v: %v", delay, err)
+
+		select {
+		case <-time.After(delay):
+		case <-p.shutdown:
+			return
+		}
+		delay = min(delay*2, p.cfg.ReconnectMaxDelay)
+	}
+}
+
+// stopWorker signals the connection worker to quit and waits for it
+func (p *COMPool) stopWorker(conn *COMConnection) {
+	conn.quitOnce.Do(func() {
+		close(conn.quit)
+	})
 
 	// Wait for worker to finish (with timeout)
 	done := make(chan struct{})
@@ -326,16 +738,24 @@ func (p *COMPool) closeConnection(conn *COMConnection) {
 	case <-time.After(p.cfg.ConnCloseTimeout):
 		p.logger.Warnf("COM connection %d worker shutdown timeout", conn.id)
 	}
+}
 
-	// Remove from connections slice
+// removeConnection removes a connection from the connections slice
+// and the idle list, the caller holds poolMutex
+func (p *COMPool) removeConnection(conn *COMConnection) bool {
+	p.idle = slices.DeleteFunc(p.idle, func(c *COMConnection) bool {
+		return c.id == conn.id
+	})
 	for i, c := range p.connections {
 		if c.id == conn.id {
 			p.connections = append(p.connections[:i], p.connections[i+1:]...)
 			p.activeCount--
-			p.logger.Infof("Closed COM connection %d, remaining: %d", conn.id, p.activeCount)
-			break
+			p.stats.Closed++
+			p.requestRefill()
+			return true
 		}
 	}
+	return false
 }
 
 // cleanupIdleConnections removes idle connections
@@ -347,6 +767,7 @@ func (p *COMPool) cleanupIdleConnections() {
 		select {
 		case <-ticker.C:
 			p.cleanup()
+			p.cleanupUserPools()
 		case <-p.shutdown:
 			return
 		}
@@ -0,0 +1,297 @@
+package gocom1c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAcquireContextCanceled(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, MaxPoolSize: 1, WaitConnTimeout: 5 * time.Second})
+
+	held, err := pool.GetConnection()
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	defer pool.ReleaseConnection(held)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		waitFor(t, "waiter queued", func() bool { return pool.Stats().Waiting == 1 })
+		cancel()
+	}()
+	_, err = pool.ExecuteCommandContext(ctx, "Ping", "{}")
+	if !errors.Is(err, context.Canceled) || ErrorCode(err) != CodeCanceled {
+		t.Fatalf("ExecuteCommandContext = %v, want %s", err, CodeCanceled)
+	}
+	if n := pool.Stats().Waiting; n != 0 {
+		t.Fatalf("Waiting = %d, want the canceled waiter gone", n)
+	}
+}
+
+func TestExecuteCommandContextCanceled(t *testing.T) {
+	b := &FakeBackend{Delay: 100 * time.Millisecond}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := pool.ExecuteCommandContext(ctx, "Slow", "{}")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ExecuteCommandContext = %v, want DeadlineExceeded", err)
+	}
+
+	// the abandoned call does not hand its connection out again
+	if _, err := pool.ExecuteCommand("Ping", "{}"); err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	if n := b.Opened(); n != 2 {
+		t.Fatalf("Opened = %d, want a replacement connection", n)
+	}
+}
+
+func TestBrokenConnectionReplaced(t *testing.T) {
+	b := &FakeBackend{
+		Handler: func(command string, params string) (string, error) {
+			if command == "Break" {
+				return "", fmt.Errorf("%w: RPC server is unavailable", ErrConnBroken)
+			}
+			return fakeEcho(command, params)
+		},
+	}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, ReconnectMinDelay: time.Millisecond})
+
+	_, err := pool.ExecuteCommand("Break", "{}")
+	if code := ErrorCode(err); code != CodeConnBroken {
+		t.Fatalf("ExecuteCommand = %v, want %s", err, CodeConnBroken)
+	}
+	if n := pool.Stats().Broken; n != 1 {
+		t.Fatalf("Broken = %d, want 1", n)
+	}
+	waitFor(t, "replacement connection", func() bool {
+		stats := pool.Stats()
+		return b.Opened() == 2 && b.Closed() == 1 && stats.Active == 1 && stats.Idle == 1
+	})
+
+	if _, err := pool.ExecuteCommand("Ping", "{}"); err != nil {
+		t.Fatalf("ExecuteCommand on the replacement: %v", err)
+	}
+}
+
+func TestIdempotentRetry(t *testing.T) {
+	var calls atomic.Int32
+	b := &FakeBackend{
+		Handler: func(command string, params string) (string, error) {
+			if calls.Add(1) == 1 {
+				return "", fmt.Errorf("%w: RPC server is unavailable", ErrConnBroken)
+			}
+			return fakeEcho(command, params)
+		},
+	}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, IdempotentCommands: []string{"Get"}})
+
+	if _, err := pool.ExecuteCommand("Get", "{}"); err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("calls = %d, want a retry", n)
+	}
+
+	calls.Store(0)
+	if _, err := pool.ExecuteCommand("Post", "{}"); ErrorCode(err) != CodeConnBroken {
+		t.Fatalf("ExecuteCommand = %v, want no retry of a command not idempotent", err)
+	}
+}
+
+func TestAcquireTimeout(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, MaxPoolSize: 1, WaitConnTimeout: 20 * time.Millisecond})
+
+	conn, err := pool.GetConnection()
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	defer pool.ReleaseConnection(conn)
+
+	if _, err := pool.GetConnection(); !errors.Is(err, ErrAcquireTimeout) {
+		t.Fatalf("GetConnection = %v, want ErrAcquireTimeout", err)
+	}
+	_, err = pool.ExecuteCommand("Ping", "{}")
+	if code := ErrorCode(err); code != CodeAcquireTimeout || !IsRetryable(err) {
+		t.Fatalf("ExecuteCommand = %v, want a retryable %s", err, CodeAcquireTimeout)
+	}
+	if n := pool.Stats().Waiting; n != 0 {
+		t.Fatalf("Waiting = %d, want the timed out waiters gone", n)
+	}
+}
+
+func TestAcquireFIFO(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, MaxPoolSize: 1, WaitConnTimeout: 5 * time.Second})
+
+	held, err := pool.GetConnection()
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+
+	const waiters = 5
+	order := make(chan int, waiters)
+	for i := range waiters {
+		go func() {
+			conn, err := pool.GetConnection()
+			if err != nil {
+				t.Errorf("waiter %d: %v", i, err)
+				order <- -1
+				return
+			}
+			order <- i
+			pool.ReleaseConnection(conn)
+		}()
+		// the next waiter is queued after this one
+		waitFor(t, fmt.Sprintf("waiter %d queued", i), func() bool { return pool.Stats().Waiting == i+1 })
+	}
+
+	pool.ReleaseConnection(held)
+	for want := range waiters {
+		if got := <-order; got != want {
+			t.Fatalf("waiter %d served in place %d", got, want)
+		}
+	}
+}
+
+func TestCloseWakesWaiters(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, MaxPoolSize: 1, WaitConnTimeout: 5 * time.Second})
+
+	if _, err := pool.GetConnection(); err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+
+	const waiters = 3
+	errs := make(chan error, waiters)
+	for range waiters {
+		go func() {
+			_, err := pool.GetConnection()
+			errs <- err
+		}()
+	}
+	waitFor(t, "waiters queued", func() bool { return pool.Stats().Waiting == waiters })
+
+	pool.Close()
+	for range waiters {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, ErrPoolClosed) {
+				t.Fatalf("GetConnection = %v, want ErrPoolClosed", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("a waiter was not woken by Close")
+		}
+	}
+	if n := b.Closed(); n != 1 {
+		t.Fatalf("Closed = %d, want the busy connection closed too", n)
+	}
+	if _, err := pool.ExecuteCommand("Ping", "{}"); ErrorCode(err) != CodePoolClosed {
+		t.Fatalf("ExecuteCommand = %v, want %s", err, CodePoolClosed)
+	}
+}
+
+func TestAcquireFailedOpenWakesWaiter(t *testing.T) {
+	b := &FakeBackend{OpenDelay: 50 * time.Millisecond}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, MaxPoolSize: 2, WaitConnTimeout: 5 * time.Second})
+
+	held, err := pool.GetConnection()
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	defer pool.ReleaseConnection(held)
+
+	b.SetOpenErr(&ConnectError{Phase: PhaseConnect, Err: errors.New("infobase is down")})
+	errs := make(chan error, 2)
+	go func() {
+		_, err := pool.GetConnection()
+		errs <- err
+	}()
+	waitFor(t, "connection opening", func() bool { return pool.Stats().Pending == 1 })
+	start := time.Now()
+	go func() {
+		_, err := pool.GetConnection()
+		errs <- err
+	}()
+	waitFor(t, "waiter queued", func() bool { return pool.Stats().Waiting == 1 })
+
+	// the waiter tries the slot of the failed open instead of timing out
+	for range 2 {
+		if err := <-errs; ErrorCode(err) != CodeConnectFailed {
+			t.Fatalf("GetConnection = %v, want %s", err, CodeConnectFailed)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("the waiter failed after %v, want at once", elapsed)
+	}
+
+	b.SetOpenErr(nil)
+	conn, err := pool.GetConnection()
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	pool.ReleaseConnection(conn)
+}
+
+func TestIdleEviction(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{
+		MinPoolSize:     1,
+		MaxPoolSize:     3,
+		IdleTimeout:     20 * time.Millisecond,
+		CleanupIdleConn: 10 * time.Millisecond,
+	})
+
+	var conns []*COMConnection
+	for range 3 {
+		conn, err := pool.GetConnection()
+		if err != nil {
+			t.Fatalf("GetConnection: %v", err)
+		}
+		conns = append(conns, conn)
+	}
+	for _, conn := range conns {
+		pool.ReleaseConnection(conn)
+	}
+
+	// the pool shrinks back to MinPoolSize
+	waitFor(t, "idle connections evicted", func() bool { return pool.Stats().Active == 1 })
+	if stats := pool.Stats(); stats.IdleEvicted != 2 || stats.Idle != 1 {
+		t.Fatalf("stats = %+v, want 2 evicted and 1 idle", stats)
+	}
+}
+
+func TestIdleLRU(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, MaxPoolSize: 2})
+
+	first, err := pool.GetConnection()
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	second, err := pool.GetConnection()
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	pool.ReleaseConnection(first)
+	time.Sleep(time.Millisecond)
+	pool.ReleaseConnection(second)
+
+	// the most recently used connection is handed out, the other ages out
+	conn, err := pool.GetConnection()
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	defer pool.ReleaseConnection(conn)
+	if conn != second {
+		t.Fatalf("got connection %d, want the most recently used %d", conn.id, second.id)
+	}
+}
@@ -0,0 +1,108 @@
+package gocom1c
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// checkConnection probes a connection taken out of the idle list.
+// A failed connection is released as broken, which discards it and
+// spawns a replacement.
+func (p *COMPool) checkConnection(ctx context.Context, conn *COMConnection) bool {
+	return p.maintain(ctx, conn, Session.Ping, func(err error) bool {
+		conn.mutex.Lock()
+		if err == nil {
+			conn.checked = time.Now()
+		} else {
+			conn.broken = true
+		}
+		conn.mutex.Unlock()
+
+		if err != nil {
+			p.logger.Warnf("COM connection %d failed health check: %v", conn.id, err)
+			p.releaseConnection(conn, false)
+			return false
+		}
+		return true
+	})
+}
+
+// maintain runs fn, a pool own call such as a probe, on a connection taken
+// out of the idle list, and passes its result to done. done releases
+// a failed connection and returns false, or returns true to keep it.
+//
+// The call is not tied to ctx, only CommandTimeout limits it, so that
+// a borrower gone meanwhile does not abandon it and leave a healthy
+// connection tainted. When ctx ends first, maintain returns false at once
+// and the connection is released when the call is over.
+func (p *COMPool) maintain(ctx context.Context, conn *COMConnection, fn func(s Session) error, done func(err error) bool) bool {
+	conn.mutex.Lock()
+	conn.busy = true
+	conn.mutex.Unlock()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := conn.run(context.Background(), func(s Session) (any, error) {
+			return nil, fn(s)
+		})
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		return done(err)
+	case <-ctx.Done():
+		p.logger.Debugf("COM connection %d borrower is gone, releasing it after the call", conn.id)
+		go func() {
+			if done(<-result) {
+				p.releaseConnection(conn, false)
+			}
+		}()
+		return false
+	}
+}
+
+// validateOnBorrow probes a connection that has been idle longer than
+// HealthCheckIdle before handing it out.
+func (p *COMPool) validateOnBorrow(ctx context.Context, conn *COMConnection) bool {
+	if !conn.needsCheck(p.cfg.HealthCheckIdle) {
+		return true
+	}
+	return p.checkConnection(ctx, conn)
+}
+
+// healthCheckLoop probes idle connections every HealthCheckInterval
+func (p *COMPool) healthCheckLoop() {
+	ticker := time.NewTicker(p.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.healthCheck()
+		case <-p.shutdown:
+			return
+		}
+	}
+}
+
+// healthCheck probes the connections that are free at the moment
+func (p *COMPool) healthCheck() {
+	p.poolMutex.Lock()
+	var due []*COMConnection
+	p.idle = slices.DeleteFunc(p.idle, func(conn *COMConnection) bool {
+		if conn.needsCheck(p.cfg.HealthCheckInterval) {
+			due = append(due, conn)
+			return true
+		}
+		return false
+	})
+	p.poolMutex.Unlock()
+
+	for _, conn := range due {
+		if p.checkConnection(context.Background(), conn) {
+			p.releaseConnection(conn, false)
+		}
+	}
+}
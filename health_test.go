@@ -0,0 +1,94 @@
+package gocom1c
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// acquireStale takes the idle connection of the pool and makes it due
+// for a health check
+func acquireStale(t *testing.T, pool *COMPool) *COMConnection {
+	t.Helper()
+
+	conn, _, err := pool.acquire()
+	if err != nil || conn == nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	conn.mutex.Lock()
+	conn.checked = time.Time{}
+	conn.lastUsed = time.Now().Add(-time.Minute)
+	conn.mutex.Unlock()
+	return conn
+}
+
+func TestHealthCheckOnBorrow(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, HealthCheckIdle: time.Second})
+
+	conn := acquireStale(t, pool)
+	b.SetPingErr(errors.New("session lost"))
+	if pool.validateOnBorrow(context.Background(), conn) {
+		t.Fatal("validateOnBorrow passed a connection that failed the probe")
+	}
+	b.SetPingErr(nil)
+
+	if _, err := pool.ExecuteCommand("Ping", "{}"); err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	if stats := pool.Stats(); stats.Broken != 1 {
+		t.Fatalf("Broken = %d, want 1", stats.Broken)
+	}
+	waitFor(t, "replacement connection", func() bool { return b.Opened() == 2 && pool.Stats().Idle == 1 })
+
+	// a connection used recently is not probed
+	conn, err := pool.GetConnection()
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	b.SetPingErr(errors.New("session lost"))
+	if !pool.validateOnBorrow(context.Background(), conn) {
+		t.Fatal("validateOnBorrow probed a connection used recently")
+	}
+	b.SetPingErr(nil)
+	pool.ReleaseConnection(conn)
+}
+
+func TestHealthCheckCanceledKeepsConnection(t *testing.T) {
+	b := &FakeBackend{PingDelay: 50 * time.Millisecond}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, HealthCheckIdle: time.Second})
+
+	// the borrower is gone before the probe starts and while it runs
+	for _, delay := range []time.Duration{0, 10 * time.Millisecond} {
+		conn := acquireStale(t, pool)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(delay, cancel)
+		start := time.Now()
+		if pool.validateOnBorrow(ctx, conn) {
+			t.Fatalf("validateOnBorrow passed after the borrower was gone")
+		}
+		if elapsed := time.Since(start); elapsed >= b.PingDelay {
+			t.Fatalf("validateOnBorrow returned after %v, want at once", elapsed)
+		}
+
+		waitFor(t, "connection back idle", func() bool { return pool.Stats().Idle == 1 })
+		stats := pool.Stats()
+		if stats.Broken != 0 || stats.Quarantined != 0 || stats.TimedOut != 0 || stats.Active != 1 {
+			t.Fatalf("stats = %+v, want the connection kept", stats)
+		}
+		if conn.IsTainted() || conn.needsCheck(time.Second) {
+			t.Fatal("the connection is not marked as probed")
+		}
+	}
+	if n := b.Opened(); n != 1 {
+		t.Fatalf("Opened = %d, want 1", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := pool.GetConnectionContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetConnectionContext = %v, want context.Canceled", err)
+	}
+}
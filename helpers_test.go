@@ -0,0 +1,60 @@
+package gocom1c
+
+import (
+	"testing"
+	"time"
+)
+
+// testLogger writes pool logs to the test log
+type testLogger struct {
+	t testing.TB
+}
+
+func (l testLogger) Infof(format string, args ...any)  { l.t.Logf("INFO "+format, args...) }
+func (l testLogger) Errorf(format string, args ...any) { l.t.Logf("ERROR "+format, args...) }
+func (l testLogger) Warnf(format string, args ...any)  { l.t.Logf("WARN "+format, args...) }
+func (l testLogger) Debugf(format string, args ...any) { l.t.Logf("DEBUG "+format, args...) }
+
+// newTestPool creates a pool on the fake backend, closed when the test ends.
+// Zero fields of cfg get the pool defaults.
+func newTestPool(t testing.TB, b *FakeBackend, cfg Config) *COMPool {
+	t.Helper()
+
+	if cfg.ConnectionString == "" && len(cfg.Endpoints) == 0 {
+		cfg.ConnectionString = `Srvr="srv";Ref="base";`
+	}
+	cfg.Backend = b
+	if cfg.TempDir == "" {
+		cfg.TempDir = t.TempDir()
+	}
+
+	pool, err := NewCOMPool(&cfg, testLogger{t})
+	if err != nil {
+		t.Fatalf("NewCOMPool: %v", err)
+	}
+	t.Cleanup(func() { pool.Close() })
+	return pool
+}
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t testing.TB, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// closed reports whether Close has been called on the pool
+func closed(p *COMPool) bool {
+	select {
+	case <-p.shutdown:
+		return true
+	default:
+		return false
+	}
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
 	"crypto/subtle"
 	"net/http"
+
+	"github.com/dronm/gocom1c/http/config"
 )
 
+// authUserKey is the request context key of the authenticated *config.AuthUser
+type authUserKey struct{}
+
 // basicAuthMiddleware adds HTTP Basic Authentication to all routes
 func (s *Server) basicAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -14,17 +20,45 @@ func (s *Server) basicAuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Compare in constant time
-		if subtle.ConstantTimeCompare([]byte(username), []byte(s.cfg.Auth.Username)) != 1 ||
-			subtle.ConstantTimeCompare([]byte(password), []byte(s.cfg.Auth.Password)) != 1 {
+		user := s.authenticate(username, password)
+		if user == nil {
 			requireAuth(w)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authUserKey{}, user)))
 	})
 }
 
+// authenticate returns the API user with the given credentials, or nil
+func (s *Server) authenticate(username string, password string) *config.AuthUser {
+	if credentialsMatch(username, password, s.cfg.Auth.Username, s.cfg.Auth.Password) {
+		return &config.AuthUser{Username: username}
+	}
+	for i := range s.cfg.Auth.Users {
+		user := &s.cfg.Auth.Users[i]
+		if credentialsMatch(username, password, user.Username, user.Password) {
+			return user
+		}
+	}
+	return nil
+}
+
+// credentialsMatch compares credentials in constant time
+func credentialsMatch(username string, password string, wantUsername string, wantPassword string) bool {
+	if wantUsername == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(username), []byte(wantUsername)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(password), []byte(wantPassword)) == 1
+}
+
+// authUser returns the API user of the request, nil without authentication
+func authUser(r *http.Request) *config.AuthUser {
+	user, _ := r.Context().Value(authUserKey{}).(*config.AuthUser)
+	return user
+}
+
 // requireAuth sends WWW-Authenticate header for basic auth
 func requireAuth(w http.ResponseWriter) {
 	w.Header().Set("WWW-Authenticate", `Basic realm="restricted"`)
@@ -26,6 +26,9 @@ const (
 type COMConfig struct {
 	ConnectionString string   `json:"connectionString"`
 	CommandExec      string   `json:"commandExec"` // WebAPI
+	CommonModules    []string `json:"commonModules"`
+	ProcessingSource string   `json:"processingSource"` // catalog | file | extension
+	ProcessingFile   string   `json:"processingFile"`
 	MaxPoolSize      int      `json:"maxPoolSize"`
 	MinPoolSize      int      `json:"minPoolSize"`
 	IdleTimeout      Duration `json:"idleTimeout"`
@@ -33,12 +36,55 @@ type COMConfig struct {
 	WaitConnTimeout  Duration `json:"waitConnTimeout"`
 	CleanupIdleConn  Duration `json:"cleanupIdleConn"`
 	ConnCloseTimeout Duration `json:"connCloseTimeout"`
+	CommandTimeout   Duration `json:"commandTimeout"`
+	MaxQuarantined   int      `json:"maxQuarantined"`
+	Backend          string   `json:"backend"` // com | fake
+
+	ReconnectMinDelay  Duration `json:"reconnectMinDelay"`
+	ReconnectMaxDelay  Duration `json:"reconnectMaxDelay"`
+	IdempotentCommands []string `json:"idempotentCommands"`
+
+	HealthCheckIdle     Duration `json:"healthCheckIdle"`
+	HealthCheckInterval Duration `json:"healthCheckInterval"`
+	PingCommand         string   `json:"pingCommand"`
+
+	MaxConnLifetime    Duration `json:"maxConnLifetime"`
+	MaxConnUses        int64    `json:"maxConnUses"`
+	ConnLifetimeJitter Duration `json:"connLifetimeJitter"`
+
+	LazyStart bool `json:"lazyStart"`
+
+	ReloadCheckInterval Duration `json:"reloadCheckInterval"`
+	VersionCommand      string   `json:"versionCommand"`
+
+	TempDir        string   `json:"tempDir"`
+	TempFileMaxAge Duration `json:"tempFileMaxAge"`
+
+	Endpoints        []string `json:"endpoints"`
+	FailbackInterval Duration `json:"failbackInterval"`
+
+	UserPoolMaxSize     int      `json:"userPoolMaxSize"`
+	UserPoolIdleTimeout Duration `json:"userPoolIdleTimeout"`
+	MaxUserPools        int      `json:"maxUserPools"`
+
+	SessionIdleTimeout Duration `json:"sessionIdleTimeout"`
 }
 
 type Auth struct {
 	RequireAuth bool   `json:"requireAuth"`
 	Username    string `json:"username"`
 	Password    string `json:"password"`
+	// Users are API users besides Username
+	Users []AuthUser `json:"users"`
+}
+
+// AuthUser is an API user. When OneCUser is set, its commands run as
+// this 1C user instead of the user of the connection string.
+type AuthUser struct {
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	OneCUser     string `json:"oneCUser"`
+	OneCPassword string `json:"oneCPassword"`
 }
 
 type Config struct {
@@ -54,6 +100,14 @@ type Config struct {
 	IdleTimeout  Duration `json:"idleTimeout"`
 
 	COM COMConfig `json:"com"`
+	// Bases are the infobases served by name. When empty, COM is
+	// the only one, named "default". Requests naming no infobase
+	// go to DefaultBase, the first name in sorted order by default.
+	Bases       map[string]COMConfig `json:"bases"`
+	DefaultBase string               `json:"defaultBase"`
+
+	// Queries is the allowlist of queries served by /query, by name
+	Queries map[string]string `json:"queries"`
 }
 
 // ReadConf reads configuration from json file
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"mime"
 	"net/http"
 	"os"
@@ -14,21 +15,41 @@ import (
 
 	com_pool "github.com/dronm/gocom1c"
 	"github.com/dronm/gocom1c/http/logger"
+	"github.com/gorilla/mux"
 )
 
 const errPoolNotInitialized = "pool not initialized"
 
 // APIRequest structure for API calls
 type APIRequest struct {
-	Command string          `json:"command"`
-	Params  json.RawMessage `json:"params"`
+	Command  string          `json:"command"`
+	Params   json.RawMessage `json:"params"`
+	Infobase string          `json:"infobase"` // default infobase when empty
+}
+
+// QueryRequest structure for query calls. Name is a query
+// from the allowlist in the config.
+type QueryRequest struct {
+	Name     string         `json:"name"`
+	Params   map[string]any `json:"params"`
+	Infobase string         `json:"infobase"`
+}
+
+// QueryResult is the payload of a query response
+type QueryResult struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
 }
 
 // APIResponse structure for API calls
 type APIResponse struct {
-	Success bool   `json:"success"`
-	Payload any    `json:"payload,omitempty"`
-	Error   string `json:"error,omitempty"`
+	Success   bool   `json:"success"`
+	Payload   any    `json:"payload,omitempty"`
+	Error     string `json:"error,omitempty"`
+	ErrorCode string `json:"errorCode,omitempty"`
+	Retryable bool   `json:"retryable,omitempty"`
+	// Exception is the 1C exception the command failed with
+	Exception *com_pool.OneCError `json:"exception,omitempty"`
 }
 
 // handleHealth handles health check requests
@@ -40,15 +61,33 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	s.respondJSON(w, http.StatusOK, response)
 }
 
-// handlePoolStatus returns COM pool status
+// handlePoolStatus returns COM pool status. Without an infobase in
+// the path it is the status of the default one with all others in bases.
 func (s *Server) handlePoolStatus(w http.ResponseWriter, r *http.Request) {
 	status := make(map[string]any)
 
 	var statusDescr string
-	if s.pool != nil {
+	if s.pools != nil {
 		statusDescr = "running"
-		status["connStatuses"] = s.pool.ConnStatuses()
-		status["connCount"] = s.pool.ActiveCount()
+		base, hasBase := mux.Vars(r)["base"]
+		pool, err := s.pools.Pool(base)
+		if err != nil && hasBase {
+			s.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if pool != nil {
+			maps.Copy(status, poolStatus(pool))
+		}
+		if !hasBase {
+			bases := make(map[string]any)
+			for _, name := range s.pools.Names() {
+				if pool, err := s.pools.Pool(name); err == nil {
+					bases[name] = poolStatus(pool)
+				}
+			}
+			status["bases"] = bases
+			status["defaultBase"] = s.pools.Default()
+		}
 	} else {
 		statusDescr = "stopped"
 	}
@@ -61,6 +100,57 @@ func (s *Server) handlePoolStatus(w http.ResponseWriter, r *http.Request) {
 	s.respondJSON(w, http.StatusOK, response)
 }
 
+// poolStatus returns the status of one infobase pool
+func poolStatus(pool *com_pool.COMPool) map[string]any {
+	return map[string]any{
+		"connStatuses": pool.ConnStatuses(),
+		"connCount":    pool.ActiveCount(),
+		"stats":        pool.Stats(),
+		"ready":        pool.Ready(),
+		"infobase":     pool.ConnectionString(),
+		"users":        userPoolStatus(pool),
+	}
+}
+
+// userPoolStatus returns the status of the sub-pools of 1C users
+func userPoolStatus(pool *com_pool.COMPool) map[string]any {
+	users := make(map[string]any)
+	for user, userPool := range pool.UserPools() {
+		users[user] = map[string]any{
+			"connCount": userPool.ActiveCount(),
+			"stats":     userPool.Stats(),
+		}
+	}
+	return users
+}
+
+// basePool returns the pool of the infobase named in the path or, when
+// there is none, by the request. When the API user is mapped to a 1C user,
+// it is the sub-pool of this user. It responds with an error when there
+// is no such pool.
+func (s *Server) basePool(w http.ResponseWriter, r *http.Request, infobase string) *com_pool.COMPool {
+	if s.pools == nil {
+		s.respondError(w, http.StatusBadGateway, errPoolNotInitialized)
+		return nil
+	}
+	if base, ok := mux.Vars(r)["base"]; ok {
+		infobase = base
+	}
+	pool, err := s.pools.Pool(infobase)
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return nil
+	}
+	if user := authUser(r); user != nil && user.OneCUser != "" {
+		if pool, err = pool.AsUser(user.OneCUser, user.OneCPassword); err != nil {
+			logger.Logger.Errorf("COM pool of 1C user %s: %v", user.OneCUser, err)
+			s.respondCommandError(w, err)
+			return nil
+		}
+	}
+	return pool
+}
+
 // handleNotFound handles 404 errors
 func (s *Server) handleNotFound(w http.ResponseWriter, r *http.Request) {
 	s.respondError(w, http.StatusNotFound, "endpoint not found")
@@ -114,6 +204,40 @@ func (s *Server) respondError(w http.ResponseWriter, status int, message string)
 	s.respondJSON(w, status, response)
 }
 
+// respondCommandError sends pool error response with its code
+// and a status code matching the error
+func (s *Server) respondCommandError(w http.ResponseWriter, err error) {
+	response := APIResponse{
+		Success:   false,
+		Error:     err.Error(),
+		ErrorCode: com_pool.ErrorCode(err),
+		Retryable: com_pool.IsRetryable(err),
+	}
+	if oneCErr, ok := com_pool.AsOneCError(err); ok {
+		response.Error = oneCErr.Description
+		response.Exception = oneCErr
+	}
+	s.respondJSON(w, commandErrorStatus(err), response)
+}
+
+// commandErrorStatus maps a pool error onto HTTP status code
+func commandErrorStatus(err error) int {
+	switch com_pool.ErrorCode(err) {
+	case com_pool.CodePoolClosed, com_pool.CodeAcquireTimeout, com_pool.CodeUserPoolsFull:
+		return http.StatusServiceUnavailable
+	case com_pool.CodeCommandTimeout:
+		return http.StatusGatewayTimeout
+	case com_pool.CodeConnBroken, com_pool.CodeConnectFailed:
+		return http.StatusBadGateway
+	case com_pool.CodeCanceled:
+		return http.StatusRequestTimeout
+	case com_pool.CodeSessionClosed:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // responseWriter wraps http.ResponseWriter to capture status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -127,22 +251,21 @@ func (rw *responseWriter) WriteHeader(code int) {
 
 // stop stops all com connections
 func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
-	if s.pool == nil {
+	if s.pools == nil {
 		s.respondError(w, http.StatusBadGateway, errPoolNotInitialized)
 		return
 	}
-	if err := s.pool.Close(); err != nil {
+	if err := s.pools.Close(); err != nil {
 		logger.Logger.Errorf("pool.Close(): %v", err)
 	}
-	s.pool = nil
+	s.pools = nil
 	s.respondJSON(w, http.StatusOK, nil)
 }
 
 // start starts min number of connections
 func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
-	poolCfg := NewCOMPoolCfg(s.cfg)
 	var err error
-	s.pool, err = com_pool.NewCOMPool(poolCfg, logger.Logger)
+	s.pools, err = newPoolManager(s.cfg)
 	if err != nil {
 		s.respondError(w, http.StatusInternalServerError, fmt.Errorf("NewCOMPool(): %v", err).Error())
 		return
@@ -150,6 +273,31 @@ func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
 	s.respondJSON(w, http.StatusOK, nil)
 }
 
+// handleReload reloads the command processing on all connections
+// of the infobase in the path, or of all infobases
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if _, ok := mux.Vars(r)["base"]; ok {
+		pool := s.basePool(w, r, "")
+		if pool == nil {
+			return
+		}
+		pool.Reload()
+		s.respondJSON(w, http.StatusOK, APIResponse{Success: true})
+		return
+	}
+
+	if s.pools == nil {
+		s.respondError(w, http.StatusBadGateway, errPoolNotInitialized)
+		return
+	}
+	for _, name := range s.pools.Names() {
+		if pool, err := s.pools.Pool(name); err == nil {
+			pool.Reload()
+		}
+	}
+	s.respondJSON(w, http.StatusOK, APIResponse{Success: true})
+}
+
 // handleExecute handles command execution with JSON response
 func (s *Server) handleExecute(w http.ResponseWriter, r *http.Request) {
 	s.handleCommand(w, r, false)
@@ -162,12 +310,6 @@ func (s *Server) handleGetBinData(w http.ResponseWriter, r *http.Request) {
 
 // handleCommand is the common handler for both JSON and binary responses
 func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request, returnBinary bool) {
-	// Common validation
-	if s.pool == nil {
-		s.respondError(w, http.StatusBadGateway, errPoolNotInitialized)
-		return
-	}
-
 	// Parse request
 	req, err := s.parseRequest(r)
 	if err != nil {
@@ -175,13 +317,29 @@ func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request, returnBin
 		return
 	}
 
+	pool := s.basePool(w, r, req.Infobase)
+	if pool == nil {
+		return
+	}
+
 	// Execute command with common logic
 	paramsStr := s.prepareParams(req.Params)
 
 	logger.Logger.Debugf("Executing command: %s, params: %s", req.Command, req.Params)
 
 	startTime := time.Now()
-	result, err := s.pool.ExecuteCommand(req.Command, paramsStr)
+	var result []byte
+	var bin *com_pool.Binary
+	if returnBinary {
+		// 1C returns ДвоичныеДанные, or a JSON response naming a file
+		bin, err = pool.ExecuteBinary(r.Context(), req.Command, paramsStr)
+		if err == nil {
+			defer bin.Close()
+			result = []byte(bin.Text)
+		}
+	} else {
+		result, err = pool.ExecuteCommandContext(r.Context(), req.Command, paramsStr)
+	}
 	duration := time.Since(startTime)
 
 	// Handle execution error
@@ -189,15 +347,41 @@ func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request, returnBin
 		logger.Logger.Errorf("Command execution failed: %s, error: %v, duration: %v",
 			req.Command, err, duration)
 
-		s.respondError(w, http.StatusInternalServerError, err.Error())
+		s.respondCommandError(w, err)
+		return
+	}
+
+	if bin != nil && bin.Text == "" {
+		logger.Logger.Infof("Command executed successfully: %s, duration: %v",
+			req.Command, duration)
+		s.streamBinary(w, bin)
+		return
+	}
+
+	resultAPI := s.commandResult(w, result)
+	if resultAPI == nil {
 		return
 	}
 
+	logger.Logger.Infof("Command executed successfully: %s, duration: %v",
+		req.Command, duration)
+
+	// Handle response based on type
+	if returnBinary {
+		s.handleBinaryResponse(w, pool, resultAPI)
+	} else {
+		s.handleJSONResponse(w, resultAPI)
+	}
+}
+
+// commandResult parses the response of the 1C processing. It responds
+// with an error and returns nil when the response is not a success.
+func (s *Server) commandResult(w http.ResponseWriter, result []byte) *APIResponse {
 	resultAPI := APIResponse{Success: true}
 	if len(result) > 0 {
 		if err := json.Unmarshal(result, &resultAPI); err != nil {
 			s.respondError(w, http.StatusInternalServerError, fmt.Errorf("com response Unmarshal(): %v", err).Error())
-			return
+			return nil
 		}
 	}
 	if !resultAPI.Success {
@@ -206,18 +390,57 @@ func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request, returnBin
 			errT = resultAPI.Error
 		}
 		s.respondError(w, http.StatusBadRequest, errT)
+		return nil
+	}
+	return &resultAPI
+}
+
+// handleQuery runs a query from the allowlist
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var req QueryRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber() // keep numbers exact for 1C
+	if err := decoder.Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON request")
 		return
 	}
 
-	logger.Logger.Infof("Command executed successfully: %s, duration: %v",
-		req.Command, duration)
+	text, ok := s.cfg.Queries[req.Name]
+	if !ok {
+		s.respondError(w, http.StatusForbidden, "query not allowed")
+		return
+	}
 
-	// Handle response based on type
-	if returnBinary {
-		s.handleBinaryResponse(w, &resultAPI)
-	} else {
-		s.handleJSONResponse(w, &resultAPI)
+	pool := s.basePool(w, r, req.Infobase)
+	if pool == nil {
+		return
+	}
+
+	logger.Logger.Debugf("Executing query: %s, params: %v", req.Name, req.Params)
+
+	startTime := time.Now()
+	rows, err := pool.Query(r.Context(), text, req.Params)
+	if err != nil {
+		logger.Logger.Errorf("Query failed: %s, error: %v", req.Name, err)
+		s.respondCommandError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	result := QueryResult{Columns: rows.Columns(), Rows: [][]any{}}
+	for rows.Next() {
+		result.Rows = append(result.Rows, rows.Values())
 	}
+	if err := rows.Err(); err != nil {
+		logger.Logger.Errorf("Query failed: %s, error: %v", req.Name, err)
+		s.respondCommandError(w, err)
+		return
+	}
+
+	logger.Logger.Infof("Query executed successfully: %s, rows: %d, duration: %v",
+		req.Name, len(result.Rows), time.Since(startTime))
+
+	s.respondJSON(w, http.StatusOK, APIResponse{Success: true, Payload: result})
 }
 
 // parseRequest parses JSON request body
@@ -261,13 +484,17 @@ func (s *Server) handleJSONResponse(w http.ResponseWriter, response *APIResponse
 }
 
 // handleBinaryResponse processes successful command execution for binary responses
-func (s *Server) handleBinaryResponse(w http.ResponseWriter, response *APIResponse) {
+func (s *Server) handleBinaryResponse(w http.ResponseWriter, pool *com_pool.COMPool, response *APIResponse) {
 	fileName, ok := response.Payload.(string)
 	if !ok {
 		s.respondError(w, http.StatusNotFound, "payload can not be cast to string")
 		return
 	}
 	logger.Logger.Debugf("handleBinaryResponse fileName:%s", fileName)
+	// a file in the temp directory is deleted once streamed
+	if pool.TempFiles().Track(fileName) {
+		defer pool.TempFiles().Release(fileName)
+	}
 	file, err := os.Open(fileName)
 	if err != nil {
 		s.respondError(w, http.StatusNotFound, "file not found")
@@ -293,6 +520,28 @@ func (s *Server) handleBinaryResponse(w http.ResponseWriter, response *APIRespon
 	s.streamFile(w, file)
 }
 
+// streamBinary streams binary data returned by 1C
+func (s *Server) streamBinary(w http.ResponseWriter, bin *com_pool.Binary) {
+	const bufferSize = 32 * 1024 // 32KB buffer
+
+	// detect content type from first 512 bytes
+	reader := bufio.NewReaderSize(bin, bufferSize)
+	head, _ := reader.Peek(512)
+	contentType := "application/octet-stream"
+	if len(head) > 0 {
+		contentType = http.DetectContentType(head)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment")
+	w.Header().Set("Content-Length", strconv.FormatInt(bin.Size, 10))
+
+	if _, err := io.Copy(w, reader); err != nil {
+		// Log error but headers already sent
+		logger.Logger.Errorf("Streaming error: %v", err)
+	}
+}
+
 // getContentType determines the MIME type for a file
 func (s *Server) getContentType(file *os.File, fileName string) string {
 	// First try to get from file extension
@@ -16,13 +16,33 @@ func (s *Server) setupRoutes() {
 	// Execute command
 	protected.HandleFunc("/execute", s.handleExecute).Methods("POST")
 	protected.HandleFunc("/bin-data", s.handleGetBinData).Methods("POST")
+	protected.HandleFunc("/query", s.handleQuery).Methods("POST")
 
 	protected.HandleFunc("/stop", s.handleStop).Methods("POST")
 	protected.HandleFunc("/start", s.handleStart).Methods("POST")
+	protected.HandleFunc("/reload", s.handleReload).Methods("POST")
 
 	// Pool status
 	protected.HandleFunc("/status", s.handlePoolStatus).Methods("GET")
 
+	// Commands in one transaction on a pinned connection
+	protected.HandleFunc("/sessions", s.handleBeginSession).Methods("POST")
+	protected.HandleFunc("/sessions/{id}/execute", s.handleSessionExecute).Methods("POST")
+	protected.HandleFunc("/sessions/{id}/commit", s.handleSessionCommit).Methods("POST")
+	protected.HandleFunc("/sessions/{id}/rollback", s.handleSessionRollback).Methods("POST")
+
+	// The same endpoints for one of several infobases
+	bases := protected.PathPrefix("/bases/{base}").Subrouter()
+	bases.HandleFunc("/execute", s.handleExecute).Methods("POST")
+	bases.HandleFunc("/bin-data", s.handleGetBinData).Methods("POST")
+	bases.HandleFunc("/query", s.handleQuery).Methods("POST")
+	bases.HandleFunc("/reload", s.handleReload).Methods("POST")
+	bases.HandleFunc("/status", s.handlePoolStatus).Methods("GET")
+	bases.HandleFunc("/sessions", s.handleBeginSession).Methods("POST")
+	bases.HandleFunc("/sessions/{id}/execute", s.handleSessionExecute).Methods("POST")
+	bases.HandleFunc("/sessions/{id}/commit", s.handleSessionCommit).Methods("POST")
+	bases.HandleFunc("/sessions/{id}/rollback", s.handleSessionRollback).Methods("POST")
+
 	// 404 handler
 	protected.NotFoundHandler = http.HandlerFunc(s.handleNotFound)
 
@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 	"sync"
 
 	com_pool "github.com/dronm/gocom1c"
@@ -14,7 +16,7 @@ import (
 
 // Server holds HTTP server state
 type Server struct {
-	pool   *com_pool.COMPool
+	pools  *com_pool.PoolManager
 	router *mux.Router
 	server *http.Server
 	mu     sync.RWMutex
@@ -25,7 +27,7 @@ type Server struct {
 func NewServer(cfg *config.Config) (*Server, error) {
 	s := &Server{
 		router: mux.NewRouter(),
-		cfg: cfg,
+		cfg:    cfg,
 	}
 
 	s.setupRoutes()
@@ -38,10 +40,9 @@ func (s *Server) Start() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Initialize COM pool
-	poolCfg := NewCOMPoolCfg(s.cfg)
+	// Initialize COM pools
 	var err error
-	s.pool, err = com_pool.NewCOMPool(poolCfg, logger.Logger)
+	s.pools, err = newPoolManager(s.cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create COM pool: %w", err)
 	}
@@ -84,9 +85,9 @@ func (s *Server) Stop() error {
 		logger.Logger.Errorf("HTTP server shutdown error: %v", err)
 	}
 
-	// Close COM pool
-	if s.pool != nil {
-		if err := s.pool.Close(); err != nil {
+	// Close COM pools
+	if s.pools != nil {
+		if err := s.pools.Close(); err != nil {
 			logger.Logger.Errorf("COM pool close error: %v", err)
 		}
 	}
@@ -96,16 +97,85 @@ func (s *Server) Stop() error {
 	return nil
 }
 
-func NewCOMPoolCfg(cfg *config.Config) *com_pool.Config {
+// newPoolManager creates the pools of the configured infobases. When none
+// are configured, the com section is the only infobase, named "default".
+func newPoolManager(cfg *config.Config) (*com_pool.PoolManager, error) {
+	bases := cfg.Bases
+	if len(bases) == 0 {
+		bases = map[string]config.COMConfig{com_pool.DefaultInfobase: cfg.COM}
+	}
+
+	manager := com_pool.NewPoolManager(logger.Logger)
+	for _, name := range slices.Sorted(maps.Keys(bases)) {
+		com := bases[name]
+		if err := manager.Add(name, NewCOMPoolCfg(&com)); err != nil {
+			manager.Close()
+			return nil, err
+		}
+	}
+	if cfg.DefaultBase != "" {
+		if err := manager.SetDefault(cfg.DefaultBase); err != nil {
+			manager.Close()
+			return nil, err
+		}
+	}
+	return manager, nil
+}
+
+func NewCOMPoolCfg(com *config.COMConfig) *com_pool.Config {
 	return &com_pool.Config{
-		ConnectionString: cfg.COM.ConnectionString,
-		CommandExec:      cfg.COM.CommandExec,
-		MaxPoolSize:      cfg.COM.MaxPoolSize,
-		MinPoolSize:      cfg.COM.MinPoolSize,
-		IdleTimeout:      cfg.COM.IdleTimeout.Duration,
-		COMObjectID:      cfg.COM.COMObjectID,
-		WaitConnTimeout:  cfg.COM.WaitConnTimeout.Duration,
-		CleanupIdleConn:  cfg.COM.CleanupIdleConn.Duration,
-		ConnCloseTimeout: cfg.COM.ConnCloseTimeout.Duration,
+		ConnectionString: com.ConnectionString,
+		CommandExec:      com.CommandExec,
+		CommonModules:    com.CommonModules,
+		ProcessingSource: com.ProcessingSource,
+		ProcessingFile:   com.ProcessingFile,
+		MaxPoolSize:      com.MaxPoolSize,
+		MinPoolSize:      com.MinPoolSize,
+		IdleTimeout:      com.IdleTimeout.Duration,
+		COMObjectID:      com.COMObjectID,
+		WaitConnTimeout:  com.WaitConnTimeout.Duration,
+		CleanupIdleConn:  com.CleanupIdleConn.Duration,
+		ConnCloseTimeout: com.ConnCloseTimeout.Duration,
+		CommandTimeout:   com.CommandTimeout.Duration,
+		MaxQuarantined:   com.MaxQuarantined,
+		Backend:          newCOMBackend(com.Backend),
+
+		ReconnectMinDelay:  com.ReconnectMinDelay.Duration,
+		ReconnectMaxDelay:  com.ReconnectMaxDelay.Duration,
+		IdempotentCommands: com.IdempotentCommands,
+
+		HealthCheckIdle:     com.HealthCheckIdle.Duration,
+		HealthCheckInterval: com.HealthCheckInterval.Duration,
+		PingCommand:         com.PingCommand,
+
+		MaxConnLifetime:    com.MaxConnLifetime.Duration,
+		MaxConnUses:        com.MaxConnUses,
+		ConnLifetimeJitter: com.ConnLifetimeJitter.Duration,
+
+		LazyStart: com.LazyStart,
+
+		ReloadCheckInterval: com.ReloadCheckInterval.Duration,
+		VersionCommand:      com.VersionCommand,
+
+		TempDir:        com.TempDir,
+		TempFileMaxAge: com.TempFileMaxAge.Duration,
+
+		Endpoints:        com.Endpoints,
+		FailbackInterval: com.FailbackInterval.Duration,
+
+		UserPoolMaxSize:     com.UserPoolMaxSize,
+		UserPoolIdleTimeout: com.UserPoolIdleTimeout.Duration,
+		MaxUserPools:        com.MaxUserPools,
+
+		SessionIdleTimeout: com.SessionIdleTimeout.Duration,
+	}
+}
+
+// newCOMBackend returns the pool backend by its config name.
+// The fake backend lets the service run without Windows and 1C.
+func newCOMBackend(name string) com_pool.Backend {
+	if name == "fake" {
+		return &com_pool.FakeBackend{}
 	}
+	return com_pool.COMBackend{}
 }
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	com_pool "github.com/dronm/gocom1c"
+	"github.com/dronm/gocom1c/http/config"
+	"github.com/dronm/gocom1c/http/logger"
+	"github.com/gorilla/mux"
+)
+
+// SessionRequest structure for starting a session
+type SessionRequest struct {
+	Infobase string `json:"infobase"`
+	// IdleTimeout rolls the session back after this time without
+	// calls, sessionIdleTimeout of the infobase when empty
+	IdleTimeout config.Duration `json:"idleTimeout"`
+}
+
+// SessionEndRequest structure for committing or rolling back a session,
+// the body is optional
+type SessionEndRequest struct {
+	Infobase string `json:"infobase"`
+}
+
+// SessionInfo is the payload of a started session
+type SessionInfo struct {
+	ID          string          `json:"id"`
+	IdleTimeout config.Duration `json:"idleTimeout"`
+}
+
+// handleBeginSession takes a connection for a session and starts
+// a transaction on it
+func (s *Server) handleBeginSession(w http.ResponseWriter, r *http.Request) {
+	var req SessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON request")
+		return
+	}
+
+	pool := s.basePool(w, r, req.Infobase)
+	if pool == nil {
+		return
+	}
+
+	session, err := pool.BeginSession(r.Context(), req.IdleTimeout.Duration)
+	if err != nil {
+		logger.Logger.Errorf("Session begin failed: %v", err)
+		s.respondCommandError(w, err)
+		return
+	}
+
+	logger.Logger.Infof("Session %s began", session.ID())
+	s.respondJSON(w, http.StatusCreated, APIResponse{
+		Success: true,
+		Payload: SessionInfo{
+			ID:          session.ID(),
+			IdleTimeout: config.Duration{Duration: session.IdleTimeout()},
+		},
+	})
+}
+
+// handleSessionExecute executes a command within a session
+func (s *Server) handleSessionExecute(w http.ResponseWriter, r *http.Request) {
+	req, err := s.parseRequest(r)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	session := s.pinnedSession(w, r, req.Infobase)
+	if session == nil {
+		return
+	}
+
+	paramsStr := s.prepareParams(req.Params)
+
+	logger.Logger.Debugf("Executing command in session %s: %s, params: %s", session.ID(), req.Command, req.Params)
+
+	startTime := time.Now()
+	result, err := session.ExecuteCommand(r.Context(), req.Command, paramsStr)
+	duration := time.Since(startTime)
+	if err != nil {
+		logger.Logger.Errorf("Command execution failed in session %s: %s, error: %v, duration: %v",
+			session.ID(), req.Command, err, duration)
+		s.respondCommandError(w, err)
+		return
+	}
+
+	resultAPI := s.commandResult(w, result)
+	if resultAPI == nil {
+		return
+	}
+
+	logger.Logger.Infof("Command executed successfully in session %s: %s, duration: %v",
+		session.ID(), req.Command, duration)
+	s.handleJSONResponse(w, resultAPI)
+}
+
+// handleSessionCommit commits the transaction of a session and ends it
+func (s *Server) handleSessionCommit(w http.ResponseWriter, r *http.Request) {
+	s.endSession(w, r, (*com_pool.PinnedSession).Commit)
+}
+
+// handleSessionRollback rolls back the transaction of a session and ends it
+func (s *Server) handleSessionRollback(w http.ResponseWriter, r *http.Request) {
+	s.endSession(w, r, (*com_pool.PinnedSession).Rollback)
+}
+
+func (s *Server) endSession(w http.ResponseWriter, r *http.Request, end func(*com_pool.PinnedSession, context.Context) error) {
+	var req SessionEndRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.respondError(w, http.StatusBadRequest, "invalid JSON request")
+		return
+	}
+
+	session := s.pinnedSession(w, r, req.Infobase)
+	if session == nil {
+		return
+	}
+
+	if err := end(session, r.Context()); err != nil {
+		logger.Logger.Errorf("Session %s end failed: %v", session.ID(), err)
+		s.respondCommandError(w, err)
+		return
+	}
+
+	logger.Logger.Infof("Session %s ended", session.ID())
+	s.respondJSON(w, http.StatusOK, APIResponse{Success: true})
+}
+
+// pinnedSession returns the session named in the path. It is looked up
+// in the pool basePool returns. It responds with an error when there is
+// no such session.
+func (s *Server) pinnedSession(w http.ResponseWriter, r *http.Request, infobase string) *com_pool.PinnedSession {
+	pool := s.basePool(w, r, infobase)
+	if pool == nil {
+		return nil
+	}
+
+	session, err := pool.PinnedSession(mux.Vars(r)["id"])
+	if err != nil {
+		s.respondCommandError(w, err)
+		return nil
+	}
+	return session
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dronm/gocom1c/http/config"
+	"github.com/dronm/gocom1c/http/logger"
+)
+
+// newTestServer returns a server with the pools of the fake infobases
+// "default" and "trade"
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	if err := logger.Initialize("error", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	com := config.COMConfig{
+		ConnectionString: `Srvr="srv";Ref="base";`,
+		Backend:          "fake",
+		MaxPoolSize:      2,
+		TempDir:          t.TempDir(),
+	}
+	cfg := &config.Config{
+		Bases:       map[string]config.COMConfig{"default": com, "trade": com},
+		DefaultBase: "default",
+	}
+
+	s, err := NewServer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.pools, err = newPoolManager(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.pools.Close() })
+	return s
+}
+
+// doJSON sends a request to the server and decodes the response
+func doJSON(t *testing.T, s *Server, method string, path string, body any) (int, APIResponse) {
+	t.Helper()
+
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(method, path, &reqBody))
+
+	var resp APIResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s %s: %v: %s", method, path, err, rec.Body.String())
+	}
+	return rec.Code, resp
+}
+
+// beginSession starts a session and returns its ID
+func beginSession(t *testing.T, s *Server, path string, body any) string {
+	t.Helper()
+
+	code, resp := doJSON(t, s, "POST", path, body)
+	if code != http.StatusCreated || !resp.Success {
+		t.Fatalf("POST %s: %d %+v", path, code, resp)
+	}
+	info, _ := resp.Payload.(map[string]any)
+	id, _ := info["id"].(string)
+	if id == "" {
+		t.Fatalf("POST %s: no session id in %+v", path, resp)
+	}
+	return id
+}
+
+func sessionCount(t *testing.T, s *Server, base string) int {
+	t.Helper()
+
+	pool, err := s.pools.Pool(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool.SessionCount()
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	s := newTestServer(t)
+
+	id := beginSession(t, s, "/sessions", nil)
+	if n := sessionCount(t, s, "default"); n != 1 {
+		t.Fatalf("sessions = %d, want 1", n)
+	}
+
+	code, resp := doJSON(t, s, "POST", "/sessions/"+id+"/execute",
+		map[string]any{"command": "Ping", "params": map[string]any{"a": 1}})
+	if code != http.StatusOK || !resp.Success {
+		t.Fatalf("execute: %d %+v", code, resp)
+	}
+
+	code, resp = doJSON(t, s, "POST", "/sessions/"+id+"/commit", nil)
+	if code != http.StatusOK || !resp.Success {
+		t.Fatalf("commit: %d %+v", code, resp)
+	}
+	if n := sessionCount(t, s, "default"); n != 0 {
+		t.Fatalf("sessions after commit = %d, want 0", n)
+	}
+
+	code, resp = doJSON(t, s, "POST", "/sessions/"+id+"/rollback", nil)
+	if code != http.StatusNotFound || resp.ErrorCode != "session_closed" {
+		t.Fatalf("rollback of an ended session: %d %+v", code, resp)
+	}
+	code, resp = doJSON(t, s, "POST", "/sessions/"+id+"/execute", map[string]any{"command": "Ping"})
+	if code != http.StatusNotFound || resp.ErrorCode != "session_closed" {
+		t.Fatalf("execute in an ended session: %d %+v", code, resp)
+	}
+}
+
+func TestSessionInfobaseInBody(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, end := range []string{"commit", "rollback"} {
+		id := beginSession(t, s, "/sessions", map[string]any{"infobase": "trade"})
+		if n := sessionCount(t, s, "trade"); n != 1 {
+			t.Fatalf("trade sessions = %d, want 1", n)
+		}
+
+		code, resp := doJSON(t, s, "POST", "/sessions/"+id+"/execute",
+			map[string]any{"command": "Ping", "infobase": "trade"})
+		if code != http.StatusOK || !resp.Success {
+			t.Fatalf("execute: %d %+v", code, resp)
+		}
+
+		code, resp = doJSON(t, s, "POST", "/sessions/"+id+"/"+end, map[string]any{"infobase": "trade"})
+		if code != http.StatusOK || !resp.Success {
+			t.Fatalf("%s: %d %+v", end, code, resp)
+		}
+		if n := sessionCount(t, s, "trade"); n != 0 {
+			t.Fatalf("trade sessions after %s = %d, want 0", end, n)
+		}
+	}
+}
+
+func TestSessionBasePrefix(t *testing.T) {
+	s := newTestServer(t)
+
+	id := beginSession(t, s, "/bases/trade/sessions", map[string]any{"idleTimeout": "1m"})
+
+	// the session is not in the default infobase
+	code, resp := doJSON(t, s, "POST", "/sessions/"+id+"/commit", nil)
+	if code != http.StatusNotFound {
+		t.Fatalf("commit in the default infobase: %d %+v", code, resp)
+	}
+
+	code, resp = doJSON(t, s, "POST", "/bases/trade/sessions/"+id+"/rollback", nil)
+	if code != http.StatusOK || !resp.Success {
+		t.Fatalf("rollback: %d %+v", code, resp)
+	}
+	if n := sessionCount(t, s, "trade"); n != 0 {
+		t.Fatalf("trade sessions = %d, want 0", n)
+	}
+}
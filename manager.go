@@ -0,0 +1,147 @@
+package gocom1c
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// DefaultInfobase names the pool of a service serving a single infobase
+const DefaultInfobase = "default"
+
+// PoolManager holds named pools, one per infobase, each with its own Config.
+type PoolManager struct {
+	logger Logger
+	mu     sync.RWMutex
+	pools  map[string]*COMPool
+	names  []string // in the order pools were added
+	def    string   // pool used for an empty name
+}
+
+// NewPoolManager returns an empty manager.
+func NewPoolManager(logger Logger) *PoolManager {
+	return &PoolManager{
+		logger: logger,
+		pools:  make(map[string]*COMPool),
+	}
+}
+
+// Add creates the pool of an infobase with NewCOMPool. The first pool
+// added is the default one, see SetDefault.
+func (m *PoolManager) Add(name string, cfg *Config) error {
+	if name == "" {
+		return fmt.Errorf("infobase name is empty")
+	}
+
+	m.mu.RLock()
+	_, exists := m.pools[name]
+	m.mu.RUnlock()
+	if exists {
+		return fmt.Errorf("infobase %s is already added", name)
+	}
+
+	// the pool is created without the lock, it may take long to connect
+	pool, err := NewCOMPool(cfg, m.logger)
+	if err != nil {
+		return fmt.Errorf("infobase %s: %w", name, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.pools[name]; exists {
+		pool.Close()
+		return fmt.Errorf("infobase %s is already added", name)
+	}
+	m.pools[name] = pool
+	m.names = append(m.names, name)
+	if m.def == "" {
+		m.def = name
+	}
+	m.logger.Infof("COM pool of infobase %s added", name)
+	return nil
+}
+
+// SetDefault makes the pool of an infobase serve calls naming no infobase.
+func (m *PoolManager) SetDefault(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.pools[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownInfobase, name)
+	}
+	m.def = name
+	return nil
+}
+
+// Default returns the name of the default infobase.
+func (m *PoolManager) Default() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.def
+}
+
+// Pool returns the pool of an infobase, of the default one when name is empty.
+func (m *PoolManager) Pool(name string) (*COMPool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if name == "" {
+		name = m.def
+	}
+	pool, ok := m.pools[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownInfobase, name)
+	}
+	return pool, nil
+}
+
+// Names returns the infobase names in the order they were added.
+func (m *PoolManager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.names)
+}
+
+// Remove closes the pool of an infobase and removes it.
+func (m *PoolManager) Remove(name string) error {
+	m.mu.Lock()
+	pool, ok := m.pools[name]
+	if !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrUnknownInfobase, name)
+	}
+	delete(m.pools, name)
+	m.names = slices.DeleteFunc(m.names, func(n string) bool { return n == name })
+	if m.def == name {
+		m.def = ""
+		if len(m.names) > 0 {
+			m.def = m.names[0]
+		}
+	}
+	m.mu.Unlock()
+
+	m.logger.Infof("COM pool of infobase %s removed", name)
+	return pool.Close()
+}
+
+// Close closes all pools.
+func (m *PoolManager) Close() error {
+	m.mu.Lock()
+	pools := m.pools
+	m.pools = make(map[string]*COMPool)
+	m.names = nil
+	m.def = ""
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, pool := range pools {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.Close()
+		}()
+	}
+	wg.Wait()
+	return nil
+}
@@ -0,0 +1,193 @@
+package gocom1c
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// managerConfig returns the config of an infobase on the fake backend
+func managerConfig(t *testing.T, b *FakeBackend) *Config {
+	return &Config{
+		ConnectionString: `Srvr="srv";Ref="base";`,
+		Backend:          b,
+		TempDir:          t.TempDir(),
+		MinPoolSize:      1,
+	}
+}
+
+func TestPoolManager(t *testing.T) {
+	buh, trade := &FakeBackend{}, &FakeBackend{}
+	m := NewPoolManager(testLogger{t})
+	defer m.Close()
+
+	if err := m.Add("buh", managerConfig(t, buh)); err != nil {
+		t.Fatalf("Add(buh): %v", err)
+	}
+	if err := m.Add("trade", managerConfig(t, trade)); err != nil {
+		t.Fatalf("Add(trade): %v", err)
+	}
+	if names := m.Names(); !slices.Equal(names, []string{"buh", "trade"}) || m.Default() != "buh" {
+		t.Fatalf("Names = %v, Default = %s", names, m.Default())
+	}
+
+	pool, err := m.Pool("trade")
+	if err != nil {
+		t.Fatalf("Pool(trade): %v", err)
+	}
+	if _, err := pool.ExecuteCommand("Ping", "{}"); err != nil || buh.Opened() != 1 || trade.Opened() != 1 {
+		t.Fatalf("ExecuteCommand = %v, want a call to the trade infobase", err)
+	}
+	if def, err := m.Pool(""); err != nil || def == pool {
+		t.Fatalf("Pool() = %v, %v, want the buh pool", def, err)
+	}
+
+	if err := m.SetDefault("trade"); err != nil {
+		t.Fatalf("SetDefault: %v", err)
+	}
+	if def, _ := m.Pool(""); def != pool {
+		t.Fatal("Pool() is not the new default")
+	}
+	if err := m.SetDefault("hr"); !errors.Is(err, ErrUnknownInfobase) {
+		t.Fatalf("SetDefault(hr) = %v, want ErrUnknownInfobase", err)
+	}
+	if _, err := m.Pool("hr"); !errors.Is(err, ErrUnknownInfobase) {
+		t.Fatalf("Pool(hr) = %v, want ErrUnknownInfobase", err)
+	}
+}
+
+func TestPoolManagerAddErrors(t *testing.T) {
+	b := &FakeBackend{}
+	m := NewPoolManager(testLogger{t})
+	defer m.Close()
+
+	if err := m.Add("", managerConfig(t, b)); err == nil {
+		t.Fatal("Add accepted an empty name")
+	}
+	if err := m.Add("buh", managerConfig(t, b)); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := m.Add("buh", managerConfig(t, b)); err == nil {
+		t.Fatal("Add accepted a duplicate name")
+	}
+	if n := b.Opened(); n != 1 {
+		t.Fatalf("Opened = %d, a duplicate opened a pool", n)
+	}
+
+	down := &FakeBackend{}
+	down.SetOpenErr(errors.New("license not found"))
+	if err := m.Add("trade", managerConfig(t, down)); err == nil {
+		t.Fatal("Add accepted an infobase that can not be connected")
+	}
+	if names := m.Names(); !slices.Equal(names, []string{"buh"}) {
+		t.Fatalf("Names = %v after a failed Add", names)
+	}
+}
+
+func TestPoolManagerAddConcurrent(t *testing.T) {
+	b := &FakeBackend{}
+	m := NewPoolManager(testLogger{t})
+	defer m.Close()
+
+	// the pools are created concurrently, the losers are closed
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- m.Add("buh", managerConfig(t, b))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	added := 0
+	for err := range errs {
+		if err == nil {
+			added++
+		}
+	}
+	if added != 1 || len(m.Names()) != 1 {
+		t.Fatalf("added %d times, Names = %v", added, m.Names())
+	}
+	if opened, closed := b.Opened(), b.Closed(); opened-closed != 1 {
+		t.Fatalf("Opened = %d, Closed = %d, want the duplicates closed", opened, closed)
+	}
+}
+
+func TestPoolManagerRemove(t *testing.T) {
+	buh, trade := &FakeBackend{}, &FakeBackend{}
+	m := NewPoolManager(testLogger{t})
+	defer m.Close()
+
+	for name, b := range map[string]*FakeBackend{"buh": buh, "trade": trade} {
+		if err := m.Add(name, managerConfig(t, b)); err != nil {
+			t.Fatalf("Add(%s): %v", name, err)
+		}
+	}
+	def := m.Default()
+	pool, _ := m.Pool(def)
+
+	// removing the default one makes the next one default
+	if err := m.Remove(def); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if !closed(pool) {
+		t.Fatal("Remove left the pool open")
+	}
+	rest := m.Names()
+	if len(rest) != 1 || rest[0] == def || m.Default() != rest[0] {
+		t.Fatalf("Names = %v, Default = %s after removing %s", rest, m.Default(), def)
+	}
+	if _, err := m.Pool(def); !errors.Is(err, ErrUnknownInfobase) {
+		t.Fatalf("Pool(%s) = %v, want ErrUnknownInfobase", def, err)
+	}
+	if err := m.Remove(def); !errors.Is(err, ErrUnknownInfobase) {
+		t.Fatalf("Remove again = %v, want ErrUnknownInfobase", err)
+	}
+
+	if err := m.Remove(rest[0]); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if m.Default() != "" || len(m.Names()) != 0 {
+		t.Fatalf("Names = %v, Default = %q", m.Names(), m.Default())
+	}
+	if buh.Closed() != 1 || trade.Closed() != 1 {
+		t.Fatalf("Closed = %d, %d", buh.Closed(), trade.Closed())
+	}
+}
+
+func TestPoolManagerClose(t *testing.T) {
+	m := NewPoolManager(testLogger{t})
+	var pools []*COMPool
+	for _, name := range []string{"buh", "trade", "hr"} {
+		if err := m.Add(name, managerConfig(t, &FakeBackend{})); err != nil {
+			t.Fatalf("Add(%s): %v", name, err)
+		}
+		pool, _ := m.Pool(name)
+		pools = append(pools, pool)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for i, pool := range pools {
+		if !closed(pool) {
+			t.Fatalf("pool %d is left open", i)
+		}
+	}
+	if _, err := m.Pool(""); !errors.Is(err, ErrUnknownInfobase) {
+		t.Fatalf("Pool after Close = %v, want ErrUnknownInfobase", err)
+	}
+	if len(m.Names()) != 0 || m.Default() != "" {
+		t.Fatalf("Names = %v, Default = %q after Close", m.Names(), m.Default())
+	}
+
+	// the manager can be filled again
+	if err := m.Add("buh", managerConfig(t, &FakeBackend{})); err != nil {
+		t.Fatalf("Add after Close: %v", err)
+	}
+	m.Close()
+}
@@ -0,0 +1,65 @@
+package gocom1c
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OneCError is an exception raised in 1C (ВызватьИсключение or a runtime
+// error), extracted from the EXCEPINFO of the failed COM call.
+type OneCError struct {
+	// Description is the error text without the module prefix
+	Description string `json:"description"`
+	// Source is the exception source reported by the COM server
+	Source string `json:"source,omitempty"`
+	// Module and Line locate the code that raised the exception,
+	// when 1C reports them
+	Module string `json:"module,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	// HRESULT of the COM call, usually DISP_E_EXCEPTION
+	HRESULT uint32 `json:"hresult"`
+	// Code is the wCode or scode of the EXCEPINFO
+	Code uint32 `json:"code,omitempty"`
+
+	err error
+}
+
+func (e *OneCError) Error() string {
+	if e.Module != "" {
+		return fmt.Sprintf("{%s(%d)}: %s", e.Module, e.Line, e.Description)
+	}
+	return e.Description
+}
+
+// Unwrap returns the underlying COM error
+func (e *OneCError) Unwrap() error {
+	return e.err
+}
+
+// AsOneCError finds the 1C exception in the error chain
+func AsOneCError(err error) (*OneCError, bool) {
+	var oneCErr *OneCError
+	if errors.As(err, &oneCErr) {
+		return oneCErr, true
+	}
+	return nil, false
+}
+
+// oneCLocation matches the location prefix 1C puts in front of
+// an exception text: {ВнешняяОбработка.WebAPI.МодульОбъекта(123)}: text
+var oneCLocation = regexp.MustCompile(`(?s)^\{(.+?)\((\d+)(?:,\d+)?\)\}:\s*(.*)$`)
+
+// parseOneCDescription splits an exception text into the module,
+// the line and the text itself
+func parseOneCDescription(descr string) (module string, line int, text string) {
+	descr = strings.TrimSpace(descr)
+	m := oneCLocation.FindStringSubmatch(descr)
+	if m == nil {
+		return "", 0, descr
+	}
+	line, _ = strconv.Atoi(m[2])
+	return m[1], line, strings.TrimSpace(m[3])
+}
@@ -0,0 +1,78 @@
+package gocom1c
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseOneCDescription(t *testing.T) {
+	tests := []struct {
+		descr  string
+		module string
+		line   int
+		text   string
+	}{
+		{
+			descr:  "{ВнешняяОбработка.WebAPI.МодульОбъекта(123)}: Документ не найден",
+			module: "ВнешняяОбработка.WebAPI.МодульОбъекта",
+			line:   123,
+			text:   "Документ не найден",
+		},
+		{
+			// 8.3 reports the column as well
+			descr:  "{ОбщийМодуль.ОбменДаннымиСервер.Модуль(1534,5)}: Деление на 0",
+			module: "ОбщийМодуль.ОбменДаннымиСервер.Модуль",
+			line:   1534,
+			text:   "Деление на 0",
+		},
+		{
+			descr: "{Документ.РеализацияТоваровУслуг.МодульОбъекта(87)}: Ошибка при вызове метода контекста (Записать)\r\n" +
+				"по причине:\r\nНе заполнено поле \"Контрагент\"\r\n",
+			module: "Документ.РеализацияТоваровУслуг.МодульОбъекта",
+			line:   87,
+			text:   "Ошибка при вызове метода контекста (Записать)\r\nпо причине:\r\nНе заполнено поле \"Контрагент\"",
+		},
+		{
+			descr:  "  {ВнешняяОбработка.WebAPI.Форма.Форма.Форма(9)}:Значение не является значением объектного типа (Ссылка)",
+			module: "ВнешняяОбработка.WebAPI.Форма.Форма.Форма",
+			line:   9,
+			text:   "Значение не является значением объектного типа (Ссылка)",
+		},
+		{
+			descr: "Неизвестный идентификатор пользователя",
+			text:  "Неизвестный идентификатор пользователя",
+		},
+		{
+			// a location inside the text is not the one of the exception
+			descr: "Ошибка при вызове метода контекста (Выполнить): {(3, 2)}: Синтаксическая ошибка",
+			text:  "Ошибка при вызове метода контекста (Выполнить): {(3, 2)}: Синтаксическая ошибка",
+		},
+		{
+			descr: "",
+			text:  "",
+		},
+	}
+	for _, tt := range tests {
+		module, line, text := parseOneCDescription(tt.descr)
+		if module != tt.module || line != tt.line || text != tt.text {
+			t.Errorf("parseOneCDescription(%q) = %q, %d, %q, want %q, %d, %q",
+				tt.descr, module, line, text, tt.module, tt.line, tt.text)
+		}
+	}
+}
+
+func TestOneCErrorError(t *testing.T) {
+	e := &OneCError{Description: "Документ не найден", Module: "ОбщийМодуль.Обмен.Модуль", Line: 12}
+	if got, want := e.Error(), "{ОбщийМодуль.Обмен.Модуль(12)}: Документ не найден"; got != want {
+		t.Errorf("Error = %q, want %q", got, want)
+	}
+
+	wrapped := fmt.Errorf("command Post: %w", e)
+	if got, ok := AsOneCError(wrapped); !ok || got != e {
+		t.Errorf("AsOneCError = %v, %v", got, ok)
+	}
+	if _, ok := AsOneCError(errors.New("other")); ok {
+		t.Error("AsOneCError found an exception in a plain error")
+	}
+}
@@ -0,0 +1,266 @@
+package gocom1c
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// queryCommand names Query calls in CommandError
+const queryCommand = "Запрос"
+
+// Rows is the result of Query, read row by row like sql.Rows.
+// The connection stays busy until Close is called or the rows end.
+type Rows struct {
+	columns []string
+	conn    *COMConnection
+	rows    chan []any
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	row     []any
+	err     error
+}
+
+// Query runs a query in the 1C query language. params are set with
+// УстановитьПараметр, the selection is read on the worker goroutine and
+// streamed row by row. Parameters and values are converted by Converter:
+// numbers into Decimal, dates into time.Time and refs into their UUID strings.
+// CommandTimeout limits the calls to 1C, not the time rows wait to be read.
+func (p *COMPool) Query(ctx context.Context, text string, params map[string]any) (*Rows, error) {
+	conn, err := p.GetConnectionContext(ctx)
+	if err != nil {
+		return nil, &CommandError{ConnID: -1, Command: queryCommand, Phase: PhaseAcquire, Err: err}
+	}
+
+	r := &Rows{
+		conn: conn,
+		rows: make(chan []any),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	columns := make(chan []string, 1)
+
+	go func() {
+		err := conn.Do(ctx, func(s Session) error {
+			return r.read(s.Connection(), text, params, columns)
+		})
+		// stop the read if it was abandoned, before the connection is
+		// handed out again
+		r.once.Do(func() { close(r.stop) })
+		p.ReleaseConnection(conn)
+
+		if err != nil {
+			r.err = &CommandError{ConnID: conn.id, Command: queryCommand, Phase: PhaseExecute, Err: err}
+		}
+		close(r.done)
+	}()
+
+	select {
+	case r.columns = <-columns:
+		return r, nil
+	case <-r.done:
+		// an empty selection may end before its columns are picked up
+		if r.err != nil {
+			return nil, r.err
+		}
+		select {
+		case r.columns = <-columns:
+		default:
+		}
+		return r, nil
+	}
+}
+
+// read executes the query and sends its rows until they end or
+// the rows are closed
+func (r *Rows) read(conn Object, text string, params map[string]any, columns chan<- []string) error {
+	res, err := conn.Call("NewObject", "Запрос")
+	if err != nil {
+		return err
+	}
+	query, ok := res.(Object)
+	if !ok {
+		return fmt.Errorf("NewObject(Запрос) returned %T", res)
+	}
+	defer query.Release()
+
+	if err := query.Put("Текст", text); err != nil {
+		return err
+	}
+
+	conv := NewConverter(conn)
+	defer conv.Release()
+	for name, value := range params {
+		arg, err := conv.ToOneC(value)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", name, err)
+		}
+		if _, err := query.Call("УстановитьПараметр", name, arg); err != nil {
+			return fmt.Errorf("parameter %s: %w", name, err)
+		}
+	}
+
+	result, err := callObject(query, "Выполнить")
+	if err != nil {
+		return err
+	}
+	defer result.Release()
+
+	names, err := columnNames(result)
+	if err != nil {
+		return err
+	}
+	columns <- names
+
+	selection, err := callObject(result, "Выбрать")
+	if err != nil {
+		return err
+	}
+	defer selection.Release()
+
+	for {
+		next, err := selection.Call("Следующий")
+		if err != nil {
+			return err
+		}
+		if ok, _ := next.(bool); !ok {
+			return nil
+		}
+
+		row := make([]any, len(names))
+		for i, name := range names {
+			val, err := selection.Get(name)
+			if err != nil {
+				return err
+			}
+			if row[i], err = conv.FromOneC(val); err != nil {
+				return fmt.Errorf("column %s: %w", name, err)
+			}
+		}
+
+		stopped := false
+		r.conn.pauseTimeout(func() error {
+			select {
+			case r.rows <- row:
+			case <-r.stop:
+				stopped = true
+			}
+			return nil
+		})
+		if stopped {
+			return nil
+		}
+	}
+}
+
+// columnNames returns column names of a query result or a value table
+func columnNames(obj Object) ([]string, error) {
+	cols, err := getObject(obj, "Колонки")
+	if err != nil {
+		return nil, err
+	}
+	defer cols.Release()
+
+	count, err := cols.Call("Количество")
+	if err != nil {
+		return nil, err
+	}
+	n, err := intValue(count)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, n)
+	for i := range n {
+		col, err := callObject(cols, "Получить", i)
+		if err != nil {
+			return nil, err
+		}
+		name, err := col.Get("Имя")
+		col.Release()
+		if err != nil {
+			return nil, err
+		}
+		s, _ := name.(string)
+		names = append(names, s)
+	}
+	return names, nil
+}
+
+// callObject calls a method that returns an object
+func callObject(obj Object, method string, args ...any) (Object, error) {
+	res, err := obj.Call(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	resObj, ok := res.(Object)
+	if !ok {
+		return nil, fmt.Errorf("%s returned %T, not an object", method, res)
+	}
+	return resObj, nil
+}
+
+// getObject reads a property that holds an object
+func getObject(obj Object, property string) (Object, error) {
+	res, err := obj.Get(property)
+	if err != nil {
+		return nil, err
+	}
+	resObj, ok := res.(Object)
+	if !ok {
+		return nil, fmt.Errorf("%s is %T, not an object", property, res)
+	}
+	return resObj, nil
+}
+
+// Columns returns the column names.
+func (r *Rows) Columns() []string {
+	return r.columns
+}
+
+// Next advances to the next row. It returns false when the rows end
+// or the read fails, see Err.
+func (r *Rows) Next() bool {
+	select {
+	case r.row = <-r.rows:
+		return true
+	case <-r.done:
+		r.row = nil
+		return false
+	}
+}
+
+// Values returns the current row.
+func (r *Rows) Values() []any {
+	return r.row
+}
+
+// Map returns the current row keyed by column names.
+func (r *Rows) Map() map[string]any {
+	m := make(map[string]any, len(r.columns))
+	for i, name := range r.columns {
+		if i < len(r.row) {
+			m[name] = r.row[i]
+		}
+	}
+	return m
+}
+
+// Err returns the error the read failed with. It is valid after
+// Next has returned false.
+func (r *Rows) Err() error {
+	select {
+	case <-r.done:
+		return r.err
+	default:
+		return nil
+	}
+}
+
+// Close stops the read and frees the connection.
+func (r *Rows) Close() error {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+	return r.err
+}
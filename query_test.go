@@ -0,0 +1,117 @@
+package gocom1c
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// fakeQueryRoot serves Query with a selection of rows over columns
+func fakeQueryRoot(columns []string, rows [][]any) *FakeObject {
+	cols := &FakeObject{Methods: map[string]func(args ...any) (any, error){
+		"Количество": func(args ...any) (any, error) { return len(columns), nil },
+		"Получить": func(args ...any) (any, error) {
+			return &FakeObject{Props: map[string]any{"Имя": columns[args[0].(int)]}}, nil
+		},
+	}}
+
+	newSelection := func() (any, error) {
+		sel := &FakeObject{Props: map[string]any{}}
+		i := 0
+		sel.Methods = map[string]func(args ...any) (any, error){
+			"Следующий": func(args ...any) (any, error) {
+				if i == len(rows) {
+					return false, nil
+				}
+				for j, name := range columns {
+					sel.Put(name, rows[i][j])
+				}
+				i++
+				return true, nil
+			},
+		}
+		return sel, nil
+	}
+	result := &FakeObject{
+		Props: map[string]any{"Колонки": cols},
+		Methods: map[string]func(args ...any) (any, error){
+			"Выбрать": func(args ...any) (any, error) { return newSelection() },
+		},
+	}
+
+	return &FakeObject{Methods: map[string]func(args ...any) (any, error){
+		"NewObject": func(args ...any) (any, error) {
+			return &FakeObject{Methods: map[string]func(args ...any) (any, error){
+				"УстановитьПараметр": func(args ...any) (any, error) { return nil, nil },
+				"Выполнить":          func(args ...any) (any, error) { return result, nil },
+			}}, nil
+		},
+	}}
+}
+
+func TestQuery(t *testing.T) {
+	b := &FakeBackend{Root: fakeQueryRoot(
+		[]string{"Наименование", "Количество"},
+		[][]any{{"Гвозди", 10}, {"Шурупы", 2.5}},
+	)}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1})
+
+	rows, err := pool.Query(context.Background(), "ВЫБРАТЬ ...", map[string]any{"Склад": "Основной"})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if got := rows.Columns(); !slices.Equal(got, []string{"Наименование", "Количество"}) {
+		t.Fatalf("Columns = %v", got)
+	}
+	var got []map[string]any
+	for rows.Next() {
+		got = append(got, rows.Map())
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if len(got) != 2 || got[0]["Наименование"] != "Гвозди" || got[0]["Количество"] != Decimal("10") ||
+		got[1]["Количество"] != Decimal("2.5") {
+		t.Fatalf("rows = %v", got)
+	}
+	if stats := pool.Stats(); stats.Idle != 1 {
+		t.Fatalf("stats = %+v, want the connection back idle", stats)
+	}
+}
+
+func TestQueryEmpty(t *testing.T) {
+	b := &FakeBackend{Root: fakeQueryRoot([]string{"Ссылка"}, nil)}
+	pool := newTestPool(t, b, Config{MinPoolSize: 4, MaxPoolSize: 4})
+
+	// the read of an empty selection ends right after it sends the
+	// columns, so Query may see it done before it takes them
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				rows, err := pool.Query(context.Background(), "ВЫБРАТЬ ...", nil)
+				if err != nil || rows == nil {
+					t.Errorf("Query = %v, %v, want empty rows", rows, err)
+					return
+				}
+				if rows.Next() {
+					t.Errorf("Next = true, row %v", rows.Values())
+				}
+				if cols := rows.Columns(); !slices.Equal(cols, []string{"Ссылка"}) {
+					t.Errorf("Columns = %v", cols)
+				}
+				if err := rows.Close(); err != nil {
+					t.Errorf("Close: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
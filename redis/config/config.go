@@ -14,6 +14,11 @@ type Config struct {
 	Redis RedisConfig `json:"redis"`
 	// COM configuration
 	COM COMConfig `json:"com"`
+	// Bases are the infobases served by name. When empty, COM is
+	// the only one, named "default". Requests naming no infobase
+	// go to DefaultBase, the first name in sorted order by default.
+	Bases       map[string]COMConfig `json:"bases"`
+	DefaultBase string               `json:"defaultBase"`
 	// Common configuration
 	LogLevel        string   `json:"logLevel"`
 	LogToFile       bool     `json:"logToFile"`
@@ -43,16 +48,45 @@ type RedisConfig struct {
 }
 
 type COMConfig struct {
-	ConnectionString string `json:"connectionString"`
-	CommandExec      string `json:"commandExec"`
-	MaxPoolSize      int    `json:"maxPoolSize"`
-	MinPoolSize      int    `json:"minPoolSize"`
-	COMObjectID      string `json:"comObjectId"`
+	ConnectionString string   `json:"connectionString"`
+	CommandExec      string   `json:"commandExec"`
+	CommonModules    []string `json:"commonModules"`
+	ProcessingSource string   `json:"processingSource"` // catalog | file | extension
+	ProcessingFile   string   `json:"processingFile"`
+	MaxPoolSize      int      `json:"maxPoolSize"`
+	MinPoolSize      int      `json:"minPoolSize"`
+	COMObjectID      string   `json:"comObjectId"`
+	Backend          string   `json:"backend"` // com | fake
 
 	IdleTimeout      Duration `json:"idleTimeout"`
 	WaitConnTimeout  Duration `json:"waitConnTimeout"`
 	CleanupIdleConn  Duration `json:"cleanupIdleConn"`
 	ConnCloseTimeout Duration `json:"connCloseTimeout"`
+	CommandTimeout   Duration `json:"commandTimeout"`
+	MaxQuarantined   int      `json:"maxQuarantined"`
+
+	ReconnectMinDelay  Duration `json:"reconnectMinDelay"`
+	ReconnectMaxDelay  Duration `json:"reconnectMaxDelay"`
+	IdempotentCommands []string `json:"idempotentCommands"`
+
+	HealthCheckIdle     Duration `json:"healthCheckIdle"`
+	HealthCheckInterval Duration `json:"healthCheckInterval"`
+	PingCommand         string   `json:"pingCommand"`
+
+	MaxConnLifetime    Duration `json:"maxConnLifetime"`
+	MaxConnUses        int64    `json:"maxConnUses"`
+	ConnLifetimeJitter Duration `json:"connLifetimeJitter"`
+
+	LazyStart bool `json:"lazyStart"`
+
+	ReloadCheckInterval Duration `json:"reloadCheckInterval"`
+	VersionCommand      string   `json:"versionCommand"`
+
+	TempDir        string   `json:"tempDir"`
+	TempFileMaxAge Duration `json:"tempFileMaxAge"`
+
+	Endpoints        []string `json:"endpoints"`
+	FailbackInterval Duration `json:"failbackInterval"`
 }
 
 type Duration struct {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"mime"
 	"net/http"
 	"os"
@@ -22,17 +23,22 @@ type RedisCommand struct {
 	Command   string          `json:"command"`
 	Params    json.RawMessage `json:"params"`
 	RequestID string          `json:"request_id"`
-	Channel   string          `json:"channel"` // Response channel override
+	Channel   string          `json:"channel"`  // Response channel override
+	Binary    bool            `json:"binary"`   // 1C returns ДвоичныеДанные
+	Infobase  string          `json:"infobase"` // default infobase when empty
 }
 
 // RedisResponse structure for Redis responses
 type RedisResponse struct {
-	RequestID string    `json:"request_id"`
-	Success   bool      `json:"success"`
-	Payload   any       `json:"payload,omitempty"`
-	Error     string    `json:"error,omitempty"`
-	Timestamp time.Time `json:"timestamp"`
-	Channel   string    `json:"channel,omitempty"` // Response channel
+	RequestID string              `json:"request_id"`
+	Success   bool                `json:"success"`
+	Payload   any                 `json:"payload,omitempty"`
+	Error     string              `json:"error,omitempty"`
+	ErrorCode string              `json:"error_code,omitempty"` // pool error code, see gocom1c.ErrorCode
+	Retryable bool                `json:"retryable,omitempty"`
+	Exception *com_pool.OneCError `json:"exception,omitempty"` // 1C exception the command failed with
+	Timestamp time.Time           `json:"timestamp"`
+	Channel   string              `json:"channel,omitempty"` // Response channel
 }
 
 // handleCommand processes a single Redis command
@@ -75,7 +81,7 @@ func (s *RedisServer) executeCommand(cmd *RedisCommand) *RedisResponse {
 	}
 
 	// Validate pool
-	if s.pool == nil {
+	if s.pools == nil {
 		response.Success = false
 		response.Error = errPoolNotInitialized
 		return response
@@ -89,7 +95,13 @@ func (s *RedisServer) executeCommand(cmd *RedisCommand) *RedisResponse {
 		return response
 
 	case "status":
-		status := s.getPoolStatus()
+		status, err := s.getPoolStatus(cmd.Infobase)
+		if err != nil {
+			response.Success = false
+			response.Error = err.Error()
+			response.ErrorCode = com_pool.ErrorCode(err)
+			return response
+		}
 		response.Success = true
 		response.Payload = status
 		return response
@@ -111,11 +123,37 @@ func (s *RedisServer) executeCommand(cmd *RedisCommand) *RedisResponse {
 			response.Success = true
 		}
 		return response
+
+	case "reload":
+		names := s.pools.Names()
+		if cmd.Infobase != "" {
+			names = []string{cmd.Infobase}
+		}
+		for _, name := range names {
+			pool, err := s.pools.Pool(name)
+			if err != nil {
+				response.Success = false
+				response.Error = err.Error()
+				response.ErrorCode = com_pool.ErrorCode(err)
+				return response
+			}
+			pool.Reload()
+		}
+		response.Success = true
+		return response
+	}
+
+	pool, err := s.pools.Pool(cmd.Infobase)
+	if err != nil {
+		response.Success = false
+		response.Error = err.Error()
+		response.ErrorCode = com_pool.ErrorCode(err)
+		return response
 	}
 
 	// Execute COM command
 	startTime := time.Now()
-	result, err := s.executeCOMCommand(cmd.Command, cmd.Params)
+	result, err := s.executeCOMCommand(pool, cmd.Command, cmd.Params, cmd.Binary)
 	duration := time.Since(startTime)
 
 	if err != nil {
@@ -123,6 +161,15 @@ func (s *RedisServer) executeCommand(cmd *RedisCommand) *RedisResponse {
 			cmd.Command, err, duration)
 		response.Success = false
 		response.Error = err.Error()
+		// errors reported by 1C in the response body have no pool code
+		if code := com_pool.ErrorCode(err); code != com_pool.CodeUnknown {
+			response.ErrorCode = code
+			response.Retryable = com_pool.IsRetryable(err)
+		}
+		if oneCErr, ok := com_pool.AsOneCError(err); ok {
+			response.Error = oneCErr.Description
+			response.Exception = oneCErr
+		}
 		return response
 	}
 
@@ -135,12 +182,27 @@ func (s *RedisServer) executeCommand(cmd *RedisCommand) *RedisResponse {
 }
 
 // executeCOMCommand executes a COM command with params
-func (s *RedisServer) executeCOMCommand(command string, params json.RawMessage) (any, error) {
+func (s *RedisServer) executeCOMCommand(pool *com_pool.COMPool, command string, params json.RawMessage, binary bool) (any, error) {
 	paramsStr := s.prepareParams(params)
 
-	result, err := s.pool.ExecuteCommand(command, paramsStr)
-	if err != nil {
-		return nil, err
+	var result []byte
+	var err error
+	if binary {
+		// 1C returns ДвоичныеДанные, or a JSON response naming a file
+		bin, err := pool.ExecuteBinary(s.ctx, command, paramsStr)
+		if err != nil {
+			return nil, err
+		}
+		defer bin.Close()
+		if bin.Text == "" {
+			return s.handleBinaryData(bin)
+		}
+		result = []byte(bin.Text)
+	} else {
+		result, err = pool.ExecuteCommandContext(s.ctx, command, paramsStr)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if len(result) == 0 {
@@ -171,7 +233,7 @@ func (s *RedisServer) executeCOMCommand(command string, params json.RawMessage)
 		// Check if it's a file path
 		if _, err := os.Stat(fileName); err == nil {
 			// It's a file, read and return as base64
-			return s.handleBinaryFile(fileName)
+			return s.handleBinaryFile(pool, fileName)
 		}
 	}
 
@@ -179,7 +241,11 @@ func (s *RedisServer) executeCOMCommand(command string, params json.RawMessage)
 }
 
 // handleBinaryFile reads a binary file and converts it
-func (s *RedisServer) handleBinaryFile(fileName string) (any, error) {
+func (s *RedisServer) handleBinaryFile(pool *com_pool.COMPool, fileName string) (any, error) {
+	// a file in the temp directory is deleted once read
+	if pool.TempFiles().Track(fileName) {
+		defer pool.TempFiles().Release(fileName)
+	}
 	file, err := os.Open(fileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
@@ -209,21 +275,67 @@ func (s *RedisServer) handleBinaryFile(fileName string) (any, error) {
 	}, nil
 }
 
-// getPoolStatus returns COM pool status
-func (s *RedisServer) getPoolStatus() map[string]any {
+// handleBinaryData reads binary data returned by 1C
+func (s *RedisServer) handleBinaryData(bin *com_pool.Binary) (any, error) {
+	content, err := io.ReadAll(bin)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := "application/octet-stream"
+	if len(content) > 0 {
+		contentType = http.DetectContentType(content)
+	}
+
+	return map[string]any{
+		"content_type": contentType,
+		"size":         len(content),
+		"data":         content,
+	}, nil
+}
+
+// getPoolStatus returns COM pool status of an infobase. Without
+// an infobase it is the status of the default one with all others in bases.
+func (s *RedisServer) getPoolStatus(infobase string) (map[string]any, error) {
 	status := make(map[string]any)
 
 	var statusDescr string
-	if s.pool != nil {
+	if s.pools != nil {
 		statusDescr = "running"
-		status["connStatuses"] = s.pool.ConnStatuses()
-		status["connCount"] = s.pool.ActiveCount()
+		pool, err := s.pools.Pool(infobase)
+		if err != nil && infobase != "" {
+			return nil, err
+		}
+		if pool != nil {
+			maps.Copy(status, poolStatus(pool))
+		}
+		if infobase == "" {
+			bases := make(map[string]any)
+			for _, name := range s.pools.Names() {
+				if pool, err := s.pools.Pool(name); err == nil {
+					bases[name] = poolStatus(pool)
+				}
+			}
+			status["bases"] = bases
+			status["defaultBase"] = s.pools.Default()
+		}
 	} else {
 		statusDescr = "stopped"
 	}
 	status["status"] = statusDescr
 
-	return status
+	return status, nil
+}
+
+// poolStatus returns the status of one infobase pool
+func poolStatus(pool *com_pool.COMPool) map[string]any {
+	return map[string]any{
+		"connStatuses": pool.ConnStatuses(),
+		"connCount":    pool.ActiveCount(),
+		"stats":        pool.Stats(),
+		"ready":        pool.Ready(),
+		"infobase":     pool.ConnectionString(),
+	}
 }
 
 // startPool starts the COM pool
@@ -231,13 +343,12 @@ func (s *RedisServer) startPool() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.pool != nil {
+	if s.pools != nil {
 		return fmt.Errorf("pool already started")
 	}
 
-	poolCfg := NewCOMPoolCfg(s.cfg)
 	var err error
-	s.pool, err = com_pool.NewCOMPool(poolCfg, logger.Logger)
+	s.pools, err = newPoolManager(s.cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create COM pool: %w", err)
 	}
@@ -250,15 +361,15 @@ func (s *RedisServer) stopPool() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.pool == nil {
+	if s.pools == nil {
 		return fmt.Errorf("pool not initialized")
 	}
 
-	if err := s.pool.Close(); err != nil {
+	if err := s.pools.Close(); err != nil {
 		return fmt.Errorf("failed to close pool: %w", err)
 	}
 
-	s.pool = nil
+	s.pools = nil
 	return nil
 }
 
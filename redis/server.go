@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 
@@ -14,7 +16,7 @@ import (
 
 // RedisServer holds Redis server state
 type RedisServer struct {
-	pool      *com_pool.COMPool
+	pools     *com_pool.PoolManager
 	redis     *redis.Client
 	ctx       context.Context
 	cancel    context.CancelFunc
@@ -63,10 +65,9 @@ func (s *RedisServer) Start() error {
 		return fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	// Initialize COM pool
-	poolCfg := NewCOMPoolCfg(s.cfg)
+	// Initialize COM pools
 	var err error
-	s.pool, err = com_pool.NewCOMPool(poolCfg, logger.Logger)
+	s.pools, err = newPoolManager(s.cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create COM pool: %w", err)
 	}
@@ -105,9 +106,9 @@ func (s *RedisServer) Stop() error {
 		}
 	}
 
-	// Close COM pool
-	if s.pool != nil {
-		if err := s.pool.Close(); err != nil {
+	// Close COM pools
+	if s.pools != nil {
+		if err := s.pools.Close(); err != nil {
 			logger.Logger.Errorf("COM pool close error: %v", err)
 		}
 	}
@@ -161,16 +162,79 @@ func (s *RedisServer) processCommands() {
 	}
 }
 
-func NewCOMPoolCfg(cfg *config.Config) *com_pool.Config {
+// newPoolManager creates the pools of the configured infobases. When none
+// are configured, the com section is the only infobase, named "default".
+func newPoolManager(cfg *config.Config) (*com_pool.PoolManager, error) {
+	bases := cfg.Bases
+	if len(bases) == 0 {
+		bases = map[string]config.COMConfig{com_pool.DefaultInfobase: cfg.COM}
+	}
+
+	manager := com_pool.NewPoolManager(logger.Logger)
+	for _, name := range slices.Sorted(maps.Keys(bases)) {
+		com := bases[name]
+		if err := manager.Add(name, NewCOMPoolCfg(&com)); err != nil {
+			manager.Close()
+			return nil, err
+		}
+	}
+	if cfg.DefaultBase != "" {
+		if err := manager.SetDefault(cfg.DefaultBase); err != nil {
+			manager.Close()
+			return nil, err
+		}
+	}
+	return manager, nil
+}
+
+func NewCOMPoolCfg(com *config.COMConfig) *com_pool.Config {
 	return &com_pool.Config{
-		ConnectionString: cfg.COM.ConnectionString,
-		CommandExec:      cfg.COM.CommandExec,
-		MaxPoolSize:      cfg.COM.MaxPoolSize,
-		MinPoolSize:      cfg.COM.MinPoolSize,
-		IdleTimeout:      cfg.COM.IdleTimeout.Duration,
-		COMObjectID:      cfg.COM.COMObjectID,
-		WaitConnTimeout:  cfg.COM.WaitConnTimeout.Duration,
-		CleanupIdleConn:  cfg.COM.CleanupIdleConn.Duration,
-		ConnCloseTimeout: cfg.COM.ConnCloseTimeout.Duration,
+		ConnectionString: com.ConnectionString,
+		CommandExec:      com.CommandExec,
+		CommonModules:    com.CommonModules,
+		ProcessingSource: com.ProcessingSource,
+		ProcessingFile:   com.ProcessingFile,
+		MaxPoolSize:      com.MaxPoolSize,
+		MinPoolSize:      com.MinPoolSize,
+		IdleTimeout:      com.IdleTimeout.Duration,
+		COMObjectID:      com.COMObjectID,
+		WaitConnTimeout:  com.WaitConnTimeout.Duration,
+		CleanupIdleConn:  com.CleanupIdleConn.Duration,
+		ConnCloseTimeout: com.ConnCloseTimeout.Duration,
+		CommandTimeout:   com.CommandTimeout.Duration,
+		MaxQuarantined:   com.MaxQuarantined,
+		Backend:          newCOMBackend(com.Backend),
+
+		ReconnectMinDelay:  com.ReconnectMinDelay.Duration,
+		ReconnectMaxDelay:  com.ReconnectMaxDelay.Duration,
+		IdempotentCommands: com.IdempotentCommands,
+
+		HealthCheckIdle:     com.HealthCheckIdle.Duration,
+		HealthCheckInterval: com.HealthCheckInterval.Duration,
+		PingCommand:         com.PingCommand,
+
+		MaxConnLifetime:    com.MaxConnLifetime.Duration,
+		MaxConnUses:        com.MaxConnUses,
+		ConnLifetimeJitter: com.ConnLifetimeJitter.Duration,
+
+		LazyStart: com.LazyStart,
+
+		ReloadCheckInterval: com.ReloadCheckInterval.Duration,
+		VersionCommand:      com.VersionCommand,
+
+		TempDir:        com.TempDir,
+		TempFileMaxAge: com.TempFileMaxAge.Duration,
+
+		Endpoints:        com.Endpoints,
+		FailbackInterval: com.FailbackInterval.Duration,
+	}
+}
+
+// newCOMBackend returns the pool backend by its config name.
+// The fake backend lets the service run without Windows and 1C.
+func newCOMBackend(name string) com_pool.Backend {
+	if name == "fake" {
+		return &com_pool.FakeBackend{}
 	}
+	return com_pool.COMBackend{}
 }
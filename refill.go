@@ -0,0 +1,107 @@
+package gocom1c
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Ready reports whether the pool has reached MinPoolSize since it was
+// created. A pool created without LazyStart is ready at once.
+func (p *COMPool) Ready() bool {
+	select {
+	case <-p.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// WaitReady waits until the pool reaches MinPoolSize for the first time
+func (p *COMPool) WaitReady(ctx context.Context) error {
+	select {
+	case <-p.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.shutdown:
+		return ErrPoolClosed
+	}
+}
+
+func (p *COMPool) markReady() {
+	p.readyOnce.Do(func() {
+		close(p.ready)
+		p.logger.Infof("COM pool is ready")
+	})
+}
+
+// requestRefill wakes refillLoop, it never blocks
+func (p *COMPool) requestRefill() {
+	select {
+	case p.refill <- struct{}{}:
+	default:
+	}
+}
+
+// refillLoop keeps the pool at MinPoolSize. It fills the pool on lazy
+// start and after evictions or failures, retrying with exponential
+// backoff while 1C is unreachable.
+func (p *COMPool) refillLoop() {
+	ticker := time.NewTicker(p.cfg.CleanupIdleConn)
+	defer ticker.Stop()
+
+	delay := p.cfg.ReconnectMinDelay
+	for {
+		if err := p.fillMin(); err != nil {
+			p.logger.Errorf("Failed to fill pool up to %d connections, retrying in %v: %v",
+				p.cfg.MinPoolSize, delay, err)
+
+			select {
+			case <-time.After(delay):
+			case <-p.shutdown:
+				return
+			}
+			delay = min(delay*2, p.cfg.ReconnectMaxDelay)
+			continue
+		}
+
+		p.poolMutex.RLock()
+		filled := p.activeCount >= p.cfg.MinPoolSize
+		p.poolMutex.RUnlock()
+		if filled {
+			p.markReady()
+		}
+		delay = p.cfg.ReconnectMinDelay
+
+		select {
+		case <-p.refill:
+		case <-ticker.C:
+		case <-p.shutdown:
+			return
+		}
+	}
+}
+
+// fillMin creates connections until the pool has MinPoolSize of them
+func (p *COMPool) fillMin() error {
+	for {
+		select {
+		case <-p.shutdown:
+			return nil
+		default:
+		}
+
+		p.poolMutex.RLock()
+		need := p.activeCount+p.pending < p.cfg.MinPoolSize
+		p.poolMutex.RUnlock()
+		if !need {
+			return nil
+		}
+
+		err := p.createConnection()
+		if err != nil && !errors.Is(err, errPoolFull) && !errors.Is(err, ErrPoolClosed) {
+			return err
+		}
+	}
+}
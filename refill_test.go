@@ -0,0 +1,72 @@
+package gocom1c
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLazyStart(t *testing.T) {
+	b := &FakeBackend{}
+	b.SetOpenErr(&ConnectError{Phase: PhaseConnect, Err: errors.New("infobase is down")})
+	pool := newTestPool(t, b, Config{
+		MinPoolSize:       2,
+		MaxPoolSize:       2,
+		LazyStart:         true,
+		ReconnectMinDelay: time.Millisecond,
+		ReconnectMaxDelay: 5 * time.Millisecond,
+	})
+
+	if pool.Ready() {
+		t.Fatal("Ready before connecting")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pool.WaitReady(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitReady = %v, want DeadlineExceeded", err)
+	}
+
+	b.SetOpenErr(nil)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := pool.WaitReady(ctx); err != nil {
+		t.Fatalf("WaitReady: %v", err)
+	}
+	if stats := pool.Stats(); stats.Active != 2 {
+		t.Fatalf("Active = %d, want 2", stats.Active)
+	}
+}
+
+func TestNewPoolConnectFailed(t *testing.T) {
+	b := &FakeBackend{}
+	b.SetOpenErr(&ConnectError{Phase: PhaseConnect, Err: errors.New("infobase is down")})
+
+	cfg := Config{
+		ConnectionString: `Srvr="srv";Ref="base";`,
+		MinPoolSize:      1,
+		Backend:          b,
+		TempDir:          t.TempDir(),
+	}
+	if _, err := NewCOMPool(&cfg, testLogger{t}); ErrorCode(err) != CodeConnectFailed {
+		t.Fatalf("NewCOMPool = %v, want %s", err, CodeConnectFailed)
+	}
+}
+
+func TestCloseWhileOpening(t *testing.T) {
+	b := &FakeBackend{OpenDelay: 50 * time.Millisecond}
+	pool := newTestPool(t, b, Config{MinPoolSize: 2, MaxPoolSize: 2, LazyStart: true})
+
+	// the refill is opening connections
+	time.Sleep(10 * time.Millisecond)
+	pool.Close()
+
+	// a session opened after Close is not left behind
+	time.Sleep(100 * time.Millisecond)
+	if opened, closed := b.Opened(), b.Closed(); opened == 0 || opened != closed {
+		t.Fatalf("opened = %d, closed = %d, want every session closed", opened, closed)
+	}
+	if stats := pool.Stats(); stats.Active != 0 || stats.Idle != 0 {
+		t.Fatalf("stats = %+v, want no connections", stats)
+	}
+}
@@ -0,0 +1,126 @@
+package gocom1c
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Reload makes every connection recreate the command processing, to pick
+// up its new version. Idle connections are reloaded at once, busy ones
+// after their current call, before they are handed out again.
+func (p *COMPool) Reload() {
+	p.generation.Add(1)
+	p.logger.Infof("COM pool processing reload requested")
+
+	go p.reloadIdle()
+
+	for _, pool := range p.UserPools() {
+		pool.Reload()
+	}
+}
+
+// outdated reports whether the processing of the connection was loaded
+// before the last Reload
+func (p *COMPool) outdated(conn *COMConnection) bool {
+	conn.mutex.RLock()
+	defer conn.mutex.RUnlock()
+	return conn.generation != p.generation.Load()
+}
+
+// reloadConnection recreates the processing of a connection taken out of
+// the idle list. A failed connection is released as broken, which discards
+// it and spawns a replacement. When ctx ends first, the reload goes on
+// detached, see maintain.
+func (p *COMPool) reloadConnection(ctx context.Context, conn *COMConnection) bool {
+	generation := p.generation.Load()
+
+	return p.maintain(ctx, conn, Session.Reload, func(err error) bool {
+		conn.mutex.Lock()
+		if err == nil {
+			conn.generation = generation
+		} else {
+			conn.broken = true
+		}
+		conn.mutex.Unlock()
+
+		if err != nil {
+			p.logger.Warnf("COM connection %d failed to reload processing: %v", conn.id, err)
+			p.releaseConnection(conn, false)
+			return false
+		}
+
+		p.poolMutex.Lock()
+		p.stats.Reloaded++
+		p.poolMutex.Unlock()
+		p.logger.Infof("COM connection %d reloaded processing", conn.id)
+		return true
+	})
+}
+
+// reloadIdle reloads the connections that are free at the moment
+func (p *COMPool) reloadIdle() {
+	p.poolMutex.Lock()
+	var due []*COMConnection
+	p.idle = slices.DeleteFunc(p.idle, func(conn *COMConnection) bool {
+		if p.outdated(conn) {
+			due = append(due, conn)
+			return true
+		}
+		return false
+	})
+	p.poolMutex.Unlock()
+
+	for _, conn := range due {
+		if p.reloadConnection(context.Background(), conn) {
+			p.releaseConnection(conn, false)
+		}
+	}
+}
+
+// versionCheckLoop checks the processing version every ReloadCheckInterval
+func (p *COMPool) versionCheckLoop() {
+	ticker := time.NewTicker(p.cfg.ReloadCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.checkVersion()
+		case <-p.shutdown:
+			return
+		}
+	}
+}
+
+// checkVersion reads the processing version on a pooled connection
+// and reloads the processing when the version has changed
+func (p *COMPool) checkVersion() {
+	var version string
+	err := p.Do(context.Background(), func(s Session) error {
+		var err error
+		if p.cfg.VersionCommand != "" {
+			version, err = s.ExecuteCommand(p.cfg.VersionCommand, "null")
+		} else {
+			version, err = s.ProcessingVersion()
+		}
+		return err
+	})
+	if err != nil {
+		p.logger.Warnf("COM pool processing version check failed: %v", err)
+		return
+	}
+	if version == "" {
+		return
+	}
+
+	p.poolMutex.Lock()
+	prev := p.version
+	p.version = version
+	p.poolMutex.Unlock()
+
+	if prev != "" && prev != version {
+		p.logger.Infof("COM pool processing version changed from %s to %s", prev, version)
+		p.Reload()
+	}
+}
@@ -0,0 +1,69 @@
+package gocom1c
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 2, MaxPoolSize: 2})
+
+	pool.Reload()
+	waitFor(t, "idle connections reloaded", func() bool { return b.Reloaded() == 2 })
+	if stats := pool.Stats(); stats.Reloaded != 2 || stats.Idle != 2 {
+		t.Fatalf("stats = %+v, want 2 idle connections reloaded", stats)
+	}
+
+	// a reloaded connection is not reloaded again on borrow
+	if _, err := pool.ExecuteCommand("Ping", "{}"); err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	if n := b.Reloaded(); n != 2 {
+		t.Fatalf("Reloaded = %d, want 2", n)
+	}
+}
+
+func TestReloadCanceledKeepsConnection(t *testing.T) {
+	b := &FakeBackend{ReloadDelay: 50 * time.Millisecond}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1})
+
+	// the borrower is gone before the reload starts and while it runs
+	for i, delay := range []time.Duration{0, 10 * time.Millisecond} {
+		pool.generation.Add(1)
+		conn, _, err := pool.acquire()
+		if err != nil || conn == nil {
+			t.Fatalf("acquire: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(delay, cancel)
+		start := time.Now()
+		if pool.reloadConnection(ctx, conn) {
+			t.Fatal("reloadConnection passed after the borrower was gone")
+		}
+		if elapsed := time.Since(start); elapsed >= b.ReloadDelay {
+			t.Fatalf("reloadConnection returned after %v, want at once", elapsed)
+		}
+
+		waitFor(t, "connection back idle", func() bool { return pool.Stats().Idle == 1 })
+		stats := pool.Stats()
+		if stats.Broken != 0 || stats.Quarantined != 0 || stats.TimedOut != 0 || stats.Active != 1 {
+			t.Fatalf("stats = %+v, want the connection kept", stats)
+		}
+		if conn.IsTainted() || pool.outdated(conn) || stats.Reloaded != int64(i+1) {
+			t.Fatalf("the connection is not marked as reloaded, stats = %+v", stats)
+		}
+	}
+	if n := b.Opened(); n != 1 {
+		t.Fatalf("Opened = %d, want 1", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := pool.GetConnectionContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetConnectionContext = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,273 @@
+package gocom1c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Methods of the 1C global context the session transaction is managed with
+const (
+	txBegin    = "НачатьТранзакцию"
+	txCommit   = "ЗафиксироватьТранзакцию"
+	txRollback = "ОтменитьТранзакцию"
+	txActive   = "ТранзакцияАктивна"
+)
+
+// PinnedSession holds a connection of the pool for a series of calls
+// made in one 1C transaction, see COMPool.BeginSession.
+type PinnedSession struct {
+	id          string
+	pool        *COMPool
+	idleTimeout time.Duration
+	timer       *time.Timer
+	mu          sync.Mutex     // serializes calls
+	conn        *COMConnection // nil once the session has ended
+	lastUsed    time.Time
+}
+
+// BeginSession takes a connection out of the pool and starts a transaction
+// on it. Calls of the session run on this connection until Commit or
+// Rollback returns it to the pool. A session without calls for idleTimeout,
+// Config.SessionIdleTimeout when zero, is rolled back. While the session
+// lasts, it can be found by its ID with PinnedSession.
+func (p *COMPool) BeginSession(ctx context.Context, idleTimeout time.Duration) (*PinnedSession, error) {
+	if idleTimeout <= 0 {
+		idleTimeout = p.cfg.SessionIdleTimeout
+	}
+
+	conn, err := p.GetConnectionContext(ctx)
+	if err != nil {
+		return nil, &CommandError{ConnID: -1, Command: txBegin, Phase: PhaseAcquire, Err: err}
+	}
+	err = conn.Do(ctx, func(s Session) error {
+		_, err := s.Connection().Call(txBegin)
+		return err
+	})
+	if err != nil {
+		p.ReleaseConnection(conn)
+		return nil, &CommandError{ConnID: conn.id, Command: txBegin, Phase: PhaseExecute, Err: err}
+	}
+
+	ps := &PinnedSession{
+		id:          randomHex(16),
+		pool:        p,
+		idleTimeout: idleTimeout,
+		conn:        conn,
+		lastUsed:    time.Now(),
+	}
+
+	p.sessMutex.Lock()
+	select {
+	case <-p.shutdown:
+		p.sessMutex.Unlock()
+		ps.Rollback(context.Background())
+		return nil, ErrPoolClosed
+	default:
+	}
+	if p.sessions == nil {
+		p.sessions = make(map[string]*PinnedSession)
+	}
+	p.sessions[ps.id] = ps
+	p.sessMutex.Unlock()
+
+	ps.mu.Lock()
+	ps.timer = time.AfterFunc(idleTimeout, ps.expire)
+	ps.mu.Unlock()
+
+	p.logger.Debugf("Session %s began on connection %d", ps.id, conn.id)
+	return ps, nil
+}
+
+// PinnedSession returns the session with the given ID, ErrSessionClosed
+// when there is none or it has ended.
+func (p *COMPool) PinnedSession(id string) (*PinnedSession, error) {
+	p.sessMutex.Lock()
+	defer p.sessMutex.Unlock()
+
+	ps, ok := p.sessions[id]
+	if !ok {
+		return nil, ErrSessionClosed
+	}
+	return ps, nil
+}
+
+// SessionCount returns the number of sessions under way
+func (p *COMPool) SessionCount() int {
+	p.sessMutex.Lock()
+	defer p.sessMutex.Unlock()
+	return len(p.sessions)
+}
+
+// removeSession forgets an ended session
+func (p *COMPool) removeSession(id string) {
+	p.sessMutex.Lock()
+	delete(p.sessions, id)
+	p.sessMutex.Unlock()
+}
+
+// closeSessions rolls back the sessions under way
+func (p *COMPool) closeSessions() {
+	p.sessMutex.Lock()
+	sessions := p.sessions
+	p.sessions = nil
+	p.sessMutex.Unlock()
+
+	for _, ps := range sessions {
+		if err := ps.Rollback(context.Background()); err != nil {
+			p.logger.Warnf("Session %s rollback on pool close failed: %v", ps.id, err)
+		}
+	}
+}
+
+// ID returns the session ID.
+func (ps *PinnedSession) ID() string {
+	return ps.id
+}
+
+// IdleTimeout returns how long the session may go without calls.
+func (ps *PinnedSession) IdleTimeout() time.Duration {
+	return ps.idleTimeout
+}
+
+// ExecuteCommand executes a command on the connection of the session.
+// When ctx is done while the command is running, the connection is
+// discarded and the session ends with its transaction.
+func (ps *PinnedSession) ExecuteCommand(ctx context.Context, command string, params string) ([]byte, error) {
+	var result string
+	err := ps.call(func(conn *COMConnection) error {
+		var err error
+		result, err = conn.ExecuteCommandContext(ctx, command, params)
+		return err
+	})
+	if err != nil {
+		var cmdErr *CommandError
+		if !errors.As(err, &cmdErr) {
+			err = &CommandError{ConnID: -1, Command: command, Phase: PhaseAcquire, Err: err}
+		}
+		return []byte{}, err
+	}
+	return []byte(result), nil
+}
+
+// Do runs fn with the session of the pinned connection, see COMConnection.Do
+func (ps *PinnedSession) Do(ctx context.Context, fn func(s Session) error) error {
+	return ps.call(func(conn *COMConnection) error {
+		return conn.Do(ctx, fn)
+	})
+}
+
+// Commit commits the transaction and returns the connection to the pool.
+// The session ends even when the commit fails, its changes are rolled
+// back then.
+func (ps *PinnedSession) Commit(ctx context.Context) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.end(ctx, txCommit)
+}
+
+// Rollback rolls back the transaction and returns the connection
+// to the pool.
+func (ps *PinnedSession) Rollback(ctx context.Context) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.end(ctx, txRollback)
+}
+
+// call runs fn on the connection of the session. When the connection is
+// lost or stuck in an abandoned call, the session ends, as the transaction
+// is lost with it.
+func (ps *PinnedSession) call(fn func(conn *COMConnection) error) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.conn == nil {
+		return ErrSessionClosed
+	}
+	err := fn(ps.conn)
+	ps.lastUsed = time.Now()
+
+	if ps.conn.lost() {
+		ps.pool.logger.Warnf("Session %s lost connection %d: %v", ps.id, ps.conn.id, err)
+		ps.release()
+	}
+	return err
+}
+
+// end commits or rolls back the transaction and releases the connection,
+// called with mu held
+func (ps *PinnedSession) end(ctx context.Context, method string) error {
+	if ps.conn == nil {
+		return ErrSessionClosed
+	}
+
+	conn := ps.conn
+	err := conn.Do(ctx, func(s Session) error {
+		return endTransaction(s.Connection(), method)
+	})
+	ps.release()
+
+	if err != nil {
+		return &CommandError{ConnID: conn.id, Command: method, Phase: PhaseExecute, Err: err}
+	}
+	ps.pool.logger.Debugf("Session %s ended with %s", ps.id, method)
+	return nil
+}
+
+// endTransaction commits or rolls back the transaction of the connection.
+// A transaction left active, such as one a command has begun and not
+// ended, fails it with ErrConnBroken, so that the connection is discarded
+// instead of being reused with the transaction.
+func endTransaction(root Object, method string) error {
+	_, err := root.Call(method)
+	if err != nil && method == txCommit {
+		// what is left of the failed transaction is rolled back
+		root.Call(txRollback)
+	}
+
+	active, activeErr := root.Call(txActive)
+	if isActive, ok := active.(bool); activeErr == nil && ok && !isActive {
+		return err
+	}
+	if err == nil {
+		err = errors.New("transaction is left active")
+	}
+	return fmt.Errorf("%w: %w", ErrConnBroken, err)
+}
+
+// release returns the connection to the pool and forgets the session,
+// called with mu held
+func (ps *PinnedSession) release() {
+	conn := ps.conn
+	ps.conn = nil
+	if ps.timer != nil {
+		ps.timer.Stop()
+	}
+	ps.pool.removeSession(ps.id)
+	ps.pool.ReleaseConnection(conn)
+}
+
+// expire rolls the session back once it has been idle for idleTimeout
+func (ps *PinnedSession) expire() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.conn == nil {
+		return
+	}
+	if idle := time.Since(ps.lastUsed); idle < ps.idleTimeout {
+		ps.timer.Reset(ps.idleTimeout - idle)
+		return
+	}
+
+	ps.pool.logger.Warnf("Session %s idle for %v, rolling back", ps.id, ps.idleTimeout)
+	ps.pool.poolMutex.Lock()
+	ps.pool.stats.SessionsExpired++
+	ps.pool.poolMutex.Unlock()
+
+	if err := ps.end(context.Background(), txRollback); err != nil {
+		ps.pool.logger.Warnf("Session %s rollback failed: %v", ps.id, err)
+	}
+}
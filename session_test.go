@@ -0,0 +1,152 @@
+package gocom1c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSessionCommit(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, MaxPoolSize: 1})
+	ctx := context.Background()
+
+	session, err := pool.BeginSession(ctx, 0)
+	if err != nil {
+		t.Fatalf("BeginSession: %v", err)
+	}
+	if session.IdleTimeout() != pool.cfg.SessionIdleTimeout {
+		t.Fatalf("IdleTimeout = %v, want %v", session.IdleTimeout(), pool.cfg.SessionIdleTimeout)
+	}
+	if found, err := pool.PinnedSession(session.ID()); err != nil || found != session {
+		t.Fatalf("PinnedSession = %p, %v, want the session", found, err)
+	}
+	if stats := pool.Stats(); stats.Sessions != 1 || stats.Idle != 0 {
+		t.Fatalf("stats = %+v, want the connection pinned", stats)
+	}
+
+	for i := range 3 {
+		if _, err := session.ExecuteCommand(ctx, "Ping", "{}"); err != nil {
+			t.Fatalf("ExecuteCommand %d: %v", i, err)
+		}
+	}
+	if err := session.Commit(ctx); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	if stats := pool.Stats(); stats.Sessions != 0 || stats.Idle != 1 {
+		t.Fatalf("stats = %+v, want the connection back idle", stats)
+	}
+	if _, err := pool.PinnedSession(session.ID()); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("PinnedSession = %v, want ErrSessionClosed", err)
+	}
+	if err := session.Rollback(ctx); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("Rollback = %v, want ErrSessionClosed", err)
+	}
+	_, err = session.ExecuteCommand(ctx, "Ping", "{}")
+	if ErrorCode(err) != CodeSessionClosed {
+		t.Fatalf("ExecuteCommand = %v, want %s", err, CodeSessionClosed)
+	}
+}
+
+func TestSessionTransactionLeftActive(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, ReconnectMinDelay: time.Millisecond})
+	ctx := context.Background()
+
+	session, err := pool.BeginSession(ctx, 0)
+	if err != nil {
+		t.Fatalf("BeginSession: %v", err)
+	}
+	// a transaction nested by a command and not ended
+	err = session.Do(ctx, func(s Session) error {
+		_, err := s.Connection().Call(txBegin)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	if err := session.Commit(ctx); !errors.Is(err, ErrConnBroken) {
+		t.Fatalf("Commit = %v, want ErrConnBroken", err)
+	}
+	// the connection is not reused with the transaction
+	if n := pool.Stats().Broken; n != 1 {
+		t.Fatalf("Broken = %d, want 1", n)
+	}
+	waitFor(t, "replacement connection", func() bool { return b.Opened() == 2 && pool.Stats().Idle == 1 })
+}
+
+func TestSessionLostConnection(t *testing.T) {
+	b := &FakeBackend{
+		Handler: func(command string, params string) (string, error) {
+			return "", fmt.Errorf("%w: RPC server is unavailable", ErrConnBroken)
+		},
+	}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1})
+	ctx := context.Background()
+
+	session, err := pool.BeginSession(ctx, 0)
+	if err != nil {
+		t.Fatalf("BeginSession: %v", err)
+	}
+	if _, err := session.ExecuteCommand(ctx, "Post", "{}"); ErrorCode(err) != CodeConnBroken {
+		t.Fatalf("ExecuteCommand = %v, want %s", err, CodeConnBroken)
+	}
+
+	// the transaction is gone with the connection
+	if n := pool.SessionCount(); n != 0 {
+		t.Fatalf("SessionCount = %d, want 0", n)
+	}
+	if err := session.Commit(ctx); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("Commit = %v, want ErrSessionClosed", err)
+	}
+}
+
+func TestSessionExpire(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1})
+	ctx := context.Background()
+
+	session, err := pool.BeginSession(ctx, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("BeginSession: %v", err)
+	}
+
+	// calls keep the session alive
+	for range 6 {
+		time.Sleep(50 * time.Millisecond)
+		if _, err := session.ExecuteCommand(ctx, "Ping", "{}"); err != nil {
+			t.Fatalf("ExecuteCommand: %v", err)
+		}
+	}
+
+	waitFor(t, "session rolled back", func() bool { return pool.SessionCount() == 0 })
+	if stats := pool.Stats(); stats.SessionsExpired != 1 || stats.Idle != 1 {
+		t.Fatalf("stats = %+v, want the session expired and the connection idle", stats)
+	}
+	if _, err := session.ExecuteCommand(ctx, "Ping", "{}"); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("ExecuteCommand = %v, want ErrSessionClosed", err)
+	}
+}
+
+func TestSessionPoolClose(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1})
+	ctx := context.Background()
+
+	session, err := pool.BeginSession(ctx, 0)
+	if err != nil {
+		t.Fatalf("BeginSession: %v", err)
+	}
+
+	pool.Close()
+	if _, err := session.ExecuteCommand(ctx, "Ping", "{}"); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("ExecuteCommand = %v, want ErrSessionClosed", err)
+	}
+	if _, err := pool.BeginSession(ctx, 0); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("BeginSession = %v, want ErrPoolClosed", err)
+	}
+}
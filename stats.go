@@ -0,0 +1,40 @@
+package gocom1c
+
+// PoolStats is a snapshot of pool gauges and lifetime counters
+type PoolStats struct {
+	Active      int `json:"active"`      // open connections
+	Idle        int `json:"idle"`        // free connections
+	Pending     int `json:"pending"`     // connections being created
+	Waiting     int `json:"waiting"`     // callers queued for a connection
+	Quarantined int `json:"quarantined"` // connections stuck in an abandoned call
+	Sessions    int `json:"sessions"`    // pinned sessions under way
+
+	Created     int64 `json:"created"`
+	Closed      int64 `json:"closed"`
+	IdleEvicted int64 `json:"idleEvicted"`
+	Retired     int64 `json:"retired"`
+	Broken      int64 `json:"broken"`
+	TimedOut    int64 `json:"timedOut"`  // connections quarantined after an abandoned call
+	Reloaded    int64 `json:"reloaded"`  // processing reloads on connections
+	Failovers   int64 `json:"failovers"` // switches to a standby endpoint
+	Failbacks   int64 `json:"failbacks"` // switches back to a preferred endpoint
+
+	SessionsExpired int64 `json:"sessionsExpired"` // pinned sessions rolled back when idle
+}
+
+// Stats returns the current pool statistics
+func (p *COMPool) Stats() PoolStats {
+	sessions := p.SessionCount()
+
+	p.poolMutex.RLock()
+	defer p.poolMutex.RUnlock()
+
+	stats := p.stats
+	stats.Active = p.activeCount
+	stats.Idle = len(p.idle)
+	stats.Pending = p.pending
+	stats.Waiting = p.waiters.Len()
+	stats.Quarantined = len(p.quarantined)
+	stats.Sessions = sessions
+	return stats
+}
@@ -0,0 +1,257 @@
+package gocom1c
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tempFilePrefix starts the names of the directories of TempFiles,
+// only such directories are swept as orphans
+const tempFilePrefix = "gocom1c-"
+
+// TempFiles tracks temporary files of a pool: processing files written
+// on connection bootstrap and files 1C returns binary results in.
+// A file is deleted when its last reference is released.
+//
+// Files are created in a directory of their own in TempDir, which is
+// touched while the pool lives, so that pools sharing TempDir sweep
+// only the directories of pools that are gone.
+type TempFiles struct {
+	dir        string // the directory of this manager in TempDir
+	resultsDir string // TempDir when set, Track takes files in it
+	logger     Logger
+	mu         sync.Mutex
+	files      map[string]*tempFile
+	stop       chan struct{}
+	closeOnce  sync.Once
+	running    sync.WaitGroup
+}
+
+// tempFile is a tracked file
+type tempFile struct {
+	refs int
+	mu   sync.Mutex // serializes writes of the file
+}
+
+// NewTempFiles returns a manager of files in a new directory in tempDir,
+// os.TempDir() when empty. The directory is created with the first file.
+func NewTempFiles(tempDir string, logger Logger) *TempFiles {
+	resultsDir := tempDir
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	if abs, err := filepath.Abs(tempDir); err == nil {
+		tempDir = abs
+	}
+	if resultsDir != "" {
+		resultsDir = tempDir
+	}
+	return &TempFiles{
+		dir:        filepath.Join(tempDir, tempFilePrefix+randomHex(4)),
+		resultsDir: resultsDir,
+		logger:     logger,
+		files:      make(map[string]*tempFile),
+		stop:       make(chan struct{}),
+	}
+}
+
+// Dir returns the directory of the files created by Create.
+func (t *TempFiles) Dir() string {
+	return t.dir
+}
+
+// Create takes a reference to the file with the given name, writing it
+// with write when it does not exist yet. Files created with the same name
+// share the content, which is written once. An empty name gets a unique one,
+// ext is appended to it. The file is to be released with Release.
+func (t *TempFiles) Create(name string, ext string, write func(path string) error) (string, error) {
+	if name == "" {
+		name = randomHex(8)
+	}
+	path := filepath.Join(t.dir, name+ext)
+
+	t.mu.Lock()
+	f, ok := t.files[path]
+	if !ok {
+		f = &tempFile{}
+		t.files[path] = f
+	}
+	f.refs++
+	t.mu.Unlock()
+
+	f.mu.Lock()
+	var err error
+	// the file is written again if it was swept meanwhile
+	if _, statErr := os.Stat(path); statErr != nil {
+		if err = os.MkdirAll(t.dir, 0o700); err == nil {
+			err = write(path)
+		}
+	}
+	f.mu.Unlock()
+
+	if err != nil {
+		t.Release(path)
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	return path, nil
+}
+
+// Track takes a reference to an existing file, such as a file 1C has
+// written a binary result to. Only files in TempDir are tracked, and only
+// when it is set: Track returns false for others and they are never deleted.
+func (t *TempFiles) Track(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil || t.resultsDir == "" || !inDir(t.resultsDir, abs) {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, ok := t.files[abs]
+	if !ok {
+		f = &tempFile{}
+		t.files[abs] = f
+	}
+	f.refs++
+	return true
+}
+
+// Release drops a reference to a file taken by Create or Track
+// and deletes the file when it was the last one.
+func (t *TempFiles) Release(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	t.mu.Lock()
+	f, ok := t.files[path]
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+	f.refs--
+	if f.refs > 0 {
+		t.mu.Unlock()
+		return
+	}
+	delete(t.files, path)
+	t.mu.Unlock()
+
+	t.remove(path)
+}
+
+// Sweep deletes the directories of TempFiles left in TempDir by pools
+// that are gone: the ones not touched for more than maxAge.
+func (t *TempFiles) Sweep(maxAge time.Duration) {
+	tempDir := filepath.Dir(t.dir)
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.logger.Warnf("Temp files sweep failed: %v", err)
+		return
+	}
+
+	before := time.Now().Add(-maxAge)
+	removed := 0
+	for _, entry := range entries {
+		path := filepath.Join(tempDir, entry.Name())
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), tempFilePrefix) || path == t.dir {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(before) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			t.logger.Warnf("Failed to remove temp directory %s: %v", path, err)
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		t.logger.Infof("Temp files sweep removed %d orphaned directories from %s", removed, tempDir)
+	}
+}
+
+// start sweeps TempDir and keeps the directory alive until Close
+func (t *TempFiles) start(maxAge time.Duration) {
+	t.running.Add(1)
+	go func() {
+		defer t.running.Done()
+		t.Sweep(maxAge)
+		t.keepAlive(maxAge)
+	}()
+}
+
+// keepAlive touches the directory every half of maxAge until Close,
+// so that sweeps of other pools take it for a live one
+func (t *TempFiles) keepAlive(maxAge time.Duration) {
+	ticker := time.NewTicker(maxAge / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			if err := os.Chtimes(t.dir, now, now); err != nil && !os.IsNotExist(err) {
+				t.logger.Warnf("Failed to touch temp directory %s: %v", t.dir, err)
+			}
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// Close deletes all tracked files and the directory.
+func (t *TempFiles) Close() {
+	t.closeOnce.Do(func() { close(t.stop) })
+	t.running.Wait()
+
+	t.mu.Lock()
+	files := t.files
+	t.files = make(map[string]*tempFile)
+	t.mu.Unlock()
+
+	for path := range files {
+		t.remove(path)
+	}
+	if err := os.Remove(t.dir); err != nil && !os.IsNotExist(err) {
+		t.logger.Warnf("Failed to remove temp directory %s: %v", t.dir, err)
+	}
+}
+
+// remove deletes a file, a file still open by 1C is left to a later sweep
+func (t *TempFiles) remove(path string) bool {
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.logger.Warnf("Failed to remove temp file %s: %v", path, err)
+		return false
+	}
+	return err == nil
+}
+
+// inDir reports whether an absolute path is inside dir
+func inDir(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." &&
+		!strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// randomHex returns n random bytes in hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// TempFiles returns the manager of the pool temporary files. Frontends
+// track files of binary results with it and release them once served.
+func (p *COMPool) TempFiles() *TempFiles {
+	return p.tempFiles
+}
@@ -0,0 +1,183 @@
+package gocom1c
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile returns a Create write function counting its calls
+func writeFile(content string, calls *int) func(path string) error {
+	return func(path string) error {
+		*calls++
+		return os.WriteFile(path, []byte(content), 0o600)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// age sets the modification time of a file or directory into the past
+func age(t *testing.T, path string, d time.Duration) {
+	t.Helper()
+	old := time.Now().Add(-d)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTempFilesCreate(t *testing.T) {
+	tempDir := t.TempDir()
+	files := NewTempFiles(tempDir, testLogger{t})
+	if dir := files.Dir(); filepath.Dir(dir) != tempDir || !strings.HasPrefix(filepath.Base(dir), tempFilePrefix) {
+		t.Fatalf("Dir = %s, want a directory of its own in %s", dir, tempDir)
+	}
+
+	// files of the same name share the content, written once
+	var calls int
+	path, err := files.Create("proc", ".epf", writeFile("v1", &calls))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	again, err := files.Create("proc", ".epf", writeFile("v2", &calls))
+	if err != nil || again != path {
+		t.Fatalf("Create again = %s, %v, want %s", again, err, path)
+	}
+	if data, _ := os.ReadFile(path); calls != 1 || string(data) != "v1" || filepath.Dir(path) != files.Dir() {
+		t.Fatalf("%s holds %q after %d writes", path, data, calls)
+	}
+
+	files.Release(path)
+	if !exists(path) {
+		t.Fatal("the file is deleted while referenced")
+	}
+	files.Release(path)
+	if exists(path) {
+		t.Fatal("the file is left after the last release")
+	}
+
+	// an empty name gets a unique one
+	a, errA := files.Create("", ".tmp", writeFile("a", &calls))
+	b, errB := files.Create("", ".tmp", writeFile("b", &calls))
+	if errA != nil || errB != nil || a == b || filepath.Ext(a) != ".tmp" {
+		t.Fatalf("Create = %s, %v and %s, %v", a, errA, b, errB)
+	}
+
+	// a failed write leaves no reference behind
+	_, err = files.Create("broken", "", func(path string) error { return errors.New("disk full") })
+	if err == nil {
+		t.Fatal("Create ignored the write error")
+	}
+	path, err = files.Create("broken", "", writeFile("ok", &calls))
+	if data, _ := os.ReadFile(path); err != nil || string(data) != "ok" {
+		t.Fatalf("Create after a failure = %q, %v", data, err)
+	}
+
+	files.Close()
+	if exists(files.Dir()) {
+		t.Fatal("Close left the directory")
+	}
+}
+
+func TestTempFilesTrack(t *testing.T) {
+	tempDir := t.TempDir()
+	other := t.TempDir()
+	files := NewTempFiles(tempDir, testLogger{t})
+	defer files.Close()
+
+	result := filepath.Join(tempDir, "v8_1C2D_1.pdf")
+	outside := filepath.Join(other, "v8_1C2D_2.pdf")
+	for _, path := range []string{result, outside} {
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, path := range []string{outside, tempDir, filepath.Join(tempDir, "..", filepath.Base(other), "v8_1C2D_2.pdf")} {
+		if files.Track(path) {
+			t.Errorf("Track(%s) took a file outside TempDir", path)
+		}
+	}
+	files.Release(outside)
+	if !exists(outside) {
+		t.Fatal("Release deleted an untracked file")
+	}
+
+	if !files.Track(result) {
+		t.Fatalf("Track(%s) = false", result)
+	}
+	files.Release(result)
+	if exists(result) {
+		t.Fatal("a tracked file is left after release")
+	}
+
+	// without TempDir nothing is tracked, the system temp directory
+	// holds files of others
+	system := NewTempFiles("", testLogger{t})
+	defer system.Close()
+	tmp, err := os.CreateTemp("", "v8_*.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if system.Track(tmp.Name()) {
+		t.Fatal("Track took a file of the system temp directory")
+	}
+}
+
+func TestTempFilesSweep(t *testing.T) {
+	tempDir := t.TempDir()
+	files := NewTempFiles(tempDir, testLogger{t})
+	defer files.Close()
+	live := NewTempFiles(tempDir, testLogger{t})
+	defer live.Close()
+
+	var calls int
+	own, _ := files.Create("own", "", writeFile("", &calls))
+	kept, _ := live.Create("kept", "", writeFile("", &calls))
+	gone := NewTempFiles(tempDir, testLogger{t})
+	left, _ := gone.Create("left", "", writeFile("", &calls))
+
+	// a live pool touches its directory, old files in it are kept
+	age(t, kept, time.Hour)
+	age(t, files.Dir(), time.Hour)
+	age(t, gone.Dir(), time.Hour)
+	unrelated := filepath.Join(tempDir, "unrelated")
+	if err := os.Mkdir(unrelated, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	age(t, unrelated, time.Hour)
+
+	files.Sweep(time.Minute)
+	if exists(left) || exists(gone.Dir()) {
+		t.Fatal("the directory of a gone pool is left")
+	}
+	for _, path := range []string{own, kept, unrelated} {
+		if !exists(path) {
+			t.Fatalf("%s is swept", path)
+		}
+	}
+}
+
+func TestTempFilesKeepAlive(t *testing.T) {
+	files := NewTempFiles(t.TempDir(), testLogger{t})
+	var calls int
+	if _, err := files.Create("proc", "", writeFile("", &calls)); err != nil {
+		t.Fatal(err)
+	}
+	age(t, files.Dir(), time.Hour)
+
+	files.start(20 * time.Millisecond)
+	waitFor(t, "directory touched", func() bool {
+		info, err := os.Stat(files.Dir())
+		return err == nil && time.Since(info.ModTime()) < time.Minute
+	})
+
+	files.Close()
+}
@@ -0,0 +1,258 @@
+package gocom1c
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// userPool is a sub-pool whose connections log in as a 1C user
+type userPool struct {
+	password string
+	pool     *COMPool
+	lastUsed time.Time // the last AsUser call that returned the pool
+}
+
+// AsUser returns the sub-pool whose connections log in to the infobase as
+// the given 1C user, so that actions are attributed to this user in
+// the журнал регистрации. A sub-pool is created on first use with
+// the settings of the pool, except that it keeps up to UserPoolMaxSize
+// connections, and it is closed after UserPoolIdleTimeout without calls.
+// A new sub-pool is returned only once it has connected with
+// the credentials. Get the sub-pool with AsUser for every call instead
+// of keeping it.
+//
+// At most MaxUserPools sub-pools are open. When all of them are busy,
+// AsUser fails with ErrUserPoolsFull, otherwise the least recently used
+// idle one is closed to make room.
+func (p *COMPool) AsUser(user string, password string) (*COMPool, error) {
+	if p.parent != nil {
+		return p.parent.AsUser(user, password)
+	}
+	if user == "" {
+		return nil, fmt.Errorf("1C user name is empty")
+	}
+
+	pool, err := p.reserveUserPool(user, password)
+	if pool != nil || err != nil {
+		return pool, err
+	}
+
+	// the sub-pool connects without the lock, the login may take long
+	pool, err = p.newUserPool(user, password)
+
+	p.usersMutex.Lock()
+	defer p.usersMutex.Unlock()
+
+	p.creating--
+	if err != nil {
+		return nil, fmt.Errorf("1C user %s: %w", user, err)
+	}
+
+	select {
+	case <-p.shutdown:
+		// closeUserPools waits for the lock, so Close returns after this
+		pool.Close()
+		return nil, ErrPoolClosed
+	default:
+	}
+
+	if up, ok := p.users[user]; ok {
+		if up.password == password {
+			// created by a concurrent call meanwhile
+			up.lastUsed = time.Now()
+			p.closeUserPool(pool)
+			return up.pool, nil
+		}
+		// the password has changed, calls under way finish on the old
+		// sub-pool, which is closed once it is idle
+		p.draining = append(p.draining, up.pool)
+		p.logger.Infof("COM sub-pool of 1C user %s replaced after the password change", user)
+	}
+
+	if p.users == nil {
+		p.users = make(map[string]*userPool)
+	}
+	p.users[user] = &userPool{password: password, pool: pool, lastUsed: time.Now()}
+	p.logger.Infof("COM sub-pool of 1C user %s created", user)
+	return pool, nil
+}
+
+// reserveUserPool returns the sub-pool of the user when it exists.
+// Otherwise it takes a place for a new one, closing the least recently
+// used idle sub-pool when there is no room, and returns nil.
+func (p *COMPool) reserveUserPool(user string, password string) (*COMPool, error) {
+	p.usersMutex.Lock()
+	defer p.usersMutex.Unlock()
+
+	select {
+	case <-p.shutdown:
+		return nil, ErrPoolClosed
+	default:
+	}
+
+	if up, ok := p.users[user]; ok && up.password == password {
+		up.lastUsed = time.Now()
+		return up.pool, nil
+	}
+
+	if len(p.users)+len(p.draining)+p.creating >= p.cfg.MaxUserPools {
+		lru := ""
+		for name, up := range p.users {
+			if name != user && !up.pool.inUse() && (lru == "" || up.lastUsed.Before(p.users[lru].lastUsed)) {
+				lru = name
+			}
+		}
+		if lru == "" {
+			return nil, ErrUserPoolsFull
+		}
+		p.logger.Infof("Closing COM sub-pool of 1C user %s to make room for %s", lru, user)
+		p.closeUserPool(p.users[lru].pool)
+		delete(p.users, lru)
+	}
+
+	p.creating++
+	return nil, nil
+}
+
+// newUserPool creates the sub-pool of the user and opens its first
+// connection, to check the credentials
+func (p *COMPool) newUserPool(user string, password string) (*COMPool, error) {
+	cfg, err := p.userConfig(user, password)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := newCOMPool(cfg, p.logger, p)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.createConnection(); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// UserPools returns the open sub-pools by 1C user name, see AsUser.
+func (p *COMPool) UserPools() map[string]*COMPool {
+	p.usersMutex.Lock()
+	defer p.usersMutex.Unlock()
+
+	pools := make(map[string]*COMPool, len(p.users))
+	for user, up := range p.users {
+		pools[user] = up.pool
+	}
+	return pools
+}
+
+// userConfig returns the config of a sub-pool: the pool config with
+// the user credentials in every connection string
+func (p *COMPool) userConfig(user string, password string) (*Config, error) {
+	cfg := *p.cfg
+	cfg.MinPoolSize = 0
+	cfg.MaxPoolSize = p.cfg.UserPoolMaxSize
+	cfg.MaxQuarantined = min(p.cfg.MaxQuarantined, cfg.MaxPoolSize)
+	cfg.LazyStart = true
+	// the pool checks the version and reloads its sub-pools
+	cfg.ReloadCheckInterval = 0
+
+	var err error
+	if cfg.ConnectionString, err = withCredentials(p.cfg.ConnectionString, user, password); err != nil {
+		return nil, err
+	}
+	cfg.Endpoints = make([]string, len(p.cfg.Endpoints))
+	for i, conn := range p.cfg.Endpoints {
+		if cfg.Endpoints[i], err = withCredentials(conn, user, password); err != nil {
+			return nil, err
+		}
+	}
+	return &cfg, nil
+}
+
+// withCredentials replaces Usr and Pwd of a connection string
+func withCredentials(conn string, user string, password string) (string, error) {
+	if conn == "" {
+		return "", nil
+	}
+	cs, err := ParseConnectionString(conn)
+	if err != nil {
+		return "", err
+	}
+	cs.User = user
+	cs.Password = password
+	return cs.String(), nil
+}
+
+// cleanupUserPools closes the sub-pools not used for UserPoolIdleTimeout
+// and the replaced ones that are no longer in use
+func (p *COMPool) cleanupUserPools() {
+	now := time.Now()
+
+	p.usersMutex.Lock()
+	defer p.usersMutex.Unlock()
+
+	for user, up := range p.users {
+		if now.Sub(up.lastUsed) <= p.cfg.UserPoolIdleTimeout || up.pool.inUse() {
+			continue
+		}
+		delete(p.users, user)
+		p.closeUserPool(up.pool)
+		p.logger.Infof("Closing COM sub-pool of 1C user %s idle for %v",
+			user, now.Sub(up.lastUsed).Round(time.Second))
+	}
+	var draining []*COMPool
+	for _, pool := range p.draining {
+		if pool.inUse() {
+			draining = append(draining, pool)
+		} else {
+			p.closeUserPool(pool)
+		}
+	}
+	p.draining = draining
+}
+
+// closeUserPool closes a sub-pool in background, called with usersMutex
+// held. Close of the pool waits for it.
+func (p *COMPool) closeUserPool(pool *COMPool) {
+	p.closing.Add(1)
+	go func() {
+		defer p.closing.Done()
+		pool.Close()
+	}()
+}
+
+// closeUserPools closes all sub-pools
+func (p *COMPool) closeUserPools() {
+	p.usersMutex.Lock()
+	pools := p.draining
+	for _, up := range p.users {
+		pools = append(pools, up.pool)
+	}
+	p.users = nil
+	p.draining = nil
+	p.usersMutex.Unlock()
+
+	var wg sync.WaitGroup
+	for _, pool := range pools {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.Close()
+		}()
+	}
+	wg.Wait()
+
+	// and the ones closed in background, none is added once
+	// the lock is taken after shutdown
+	p.usersMutex.Lock()
+	p.closing.Wait()
+	p.usersMutex.Unlock()
+}
+
+// inUse reports whether connections of the pool are busy, being
+// created or awaited. Connections of pinned sessions are busy.
+func (p *COMPool) inUse() bool {
+	p.poolMutex.RLock()
+	defer p.poolMutex.RUnlock()
+	return len(p.idle) < p.activeCount || p.pending > 0 || p.waiters.Len() > 0
+}
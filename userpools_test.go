@@ -0,0 +1,165 @@
+package gocom1c
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// userConnString is the connection string of the test pool with credentials
+func userConnString(user string, password string) string {
+	s, _ := withCredentials(`Srvr="srv";Ref="base";`, user, password)
+	return s
+}
+
+func TestAsUser(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1})
+
+	userPool, err := pool.AsUser("Иванов", "p;w")
+	if err != nil {
+		t.Fatalf("AsUser: %v", err)
+	}
+	if got := userPool.cfg.ConnectionString; !strings.Contains(got, `Usr="Иванов";Pwd="p;w";`) {
+		t.Fatalf("ConnectionString = %s, want the user credentials", got)
+	}
+	if _, err := userPool.ExecuteCommand("Ping", "{}"); err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+
+	again, err := pool.AsUser("Иванов", "p;w")
+	if err != nil || again != userPool {
+		t.Fatalf("AsUser again = %p, %v, want the same sub-pool", again, err)
+	}
+	if n := len(pool.UserPools()); n != 1 {
+		t.Fatalf("UserPools = %d, want 1", n)
+	}
+
+	pool.Close()
+	if _, err := userPool.ExecuteCommand("Ping", "{}"); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("ExecuteCommand after Close = %v, want ErrPoolClosed", err)
+	}
+	if _, err := pool.AsUser("Иванов", "p;w"); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("AsUser after Close = %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestAsUserIdleEviction(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{
+		MinPoolSize:         1,
+		CleanupIdleConn:     10 * time.Millisecond,
+		UserPoolIdleTimeout: 30 * time.Millisecond,
+	})
+
+	userPool, err := pool.AsUser("Иванов", "pwd")
+	if err != nil {
+		t.Fatalf("AsUser: %v", err)
+	}
+	waitFor(t, "idle sub-pool closed", func() bool { return len(pool.UserPools()) == 0 && closed(userPool) })
+	if _, err := userPool.ExecuteCommand("Ping", "{}"); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("ExecuteCommand = %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestAsUserWrongPasswordKeepsPool(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1})
+
+	userPool, err := pool.AsUser("Иванов", "good")
+	if err != nil {
+		t.Fatalf("AsUser: %v", err)
+	}
+
+	b.SetDown(userConnString("Иванов", "bad"), true)
+	if _, err := pool.AsUser("Иванов", "bad"); ErrorCode(err) != CodeConnectFailed {
+		t.Fatalf("AsUser with a wrong password = %v, want %s", err, CodeConnectFailed)
+	}
+
+	again, err := pool.AsUser("Иванов", "good")
+	if err != nil || again != userPool {
+		t.Fatalf("AsUser = %p, %v, want the working sub-pool", again, err)
+	}
+	if _, err := userPool.ExecuteCommand("Ping", "{}"); err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+}
+
+func TestAsUserPasswordChangeDrains(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, CleanupIdleConn: 10 * time.Millisecond})
+
+	oldPool, err := pool.AsUser("Иванов", "old")
+	if err != nil {
+		t.Fatalf("AsUser: %v", err)
+	}
+	session, err := oldPool.BeginSession(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("BeginSession: %v", err)
+	}
+
+	newPool, err := pool.AsUser("Иванов", "new")
+	if err != nil {
+		t.Fatalf("AsUser with the new password: %v", err)
+	}
+	if newPool == oldPool {
+		t.Fatal("AsUser returned the sub-pool of the old password")
+	}
+
+	// the old sub-pool keeps serving the session under way
+	time.Sleep(30 * time.Millisecond)
+	if _, err := session.ExecuteCommand(context.Background(), "Ping", "{}"); err != nil {
+		t.Fatalf("ExecuteCommand in the session: %v", err)
+	}
+	if err := session.Commit(context.Background()); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	waitFor(t, "old sub-pool closed", func() bool { return closed(oldPool) })
+	if _, err := newPool.ExecuteCommand("Ping", "{}"); err != nil {
+		t.Fatalf("ExecuteCommand on the new sub-pool: %v", err)
+	}
+}
+
+func TestAsUserMaxUserPools(t *testing.T) {
+	b := &FakeBackend{}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, MaxUserPools: 2})
+
+	first, err := pool.AsUser("first", "")
+	if err != nil {
+		t.Fatalf("AsUser first: %v", err)
+	}
+	second, err := pool.AsUser("second", "")
+	if err != nil {
+		t.Fatalf("AsUser second: %v", err)
+	}
+
+	// both sub-pools are busy, there is no room for a third one
+	s1, err := first.BeginSession(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("BeginSession: %v", err)
+	}
+	s2, err := second.BeginSession(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("BeginSession: %v", err)
+	}
+	if _, err := pool.AsUser("third", ""); !errors.Is(err, ErrUserPoolsFull) {
+		t.Fatalf("AsUser third = %v, want ErrUserPoolsFull", err)
+	}
+
+	// an idle one makes room
+	if err := s1.Rollback(context.Background()); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if _, err := pool.AsUser("third", ""); err != nil {
+		t.Fatalf("AsUser third: %v", err)
+	}
+	users := pool.UserPools()
+	if _, ok := users["first"]; ok || len(users) != 2 {
+		t.Fatalf("UserPools = %v, want second and third", users)
+	}
+	waitFor(t, "least recently used sub-pool closed", func() bool { return closed(first) })
+	s2.Rollback(context.Background())
+}
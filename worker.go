@@ -1,199 +1,166 @@
 package gocom1c
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"runtime"
+	"sync/atomic"
+	"time"
+)
 
-	"github.com/go-ole/go-ole"
-	"github.com/go-ole/go-ole/oleutil"
+// call states used to tell an abandoned call that never started
+// from one that is still running on the worker
+const (
+	callQueued int32 = iota
+	callStarted
+	callAbandoned
 )
 
-func (c *COMConnection) comWorker(cfg *Config, ready chan<- error, logger Logger) {
-	c.wg.Add(1) 
+// worker owns the connection session. Every call to the session runs here,
+// on one locked OS thread, as COM requires.
+func (c *COMConnection) worker(cfg *Config, ready chan<- error, logger Logger) {
 	defer c.wg.Done()
 
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
 
-	// Initialize COM
-	if err := ole.CoInitialize(0); err != nil {
-		ready <- fmt.Errorf("CoInitialize failed: %w", err)
-		return
-	}
-	defer ole.CoUninitialize()
-
-	logger.Debugf("initializing COM: %s", cfg.COMObjectID)
-
-	// Create COM connector
-	unknown, err := oleutil.CreateObject(cfg.COMObjectID)
+	session, err := cfg.Backend.Open(cfg, logger)
 	if err != nil {
-		ready <- fmt.Errorf("create COMConnector failed: %w", err)
+		ready <- err
 		return
 	}
-	defer unknown.Release()
+	c.session = session
 
-	dispatch, err := unknown.QueryInterface(ole.IID_IDispatch)
-	if err != nil {
-		ready <- fmt.Errorf("QueryInterface failed: %w", err)
-		return
-	}
-	defer dispatch.Release()
-
-	logger.Debugf("trying to connect with: %s", cfg.ConnectionString)
-
-	// Connect to 1C
-	c.v8, err = oleutil.CallMethod(dispatch, "Connect", cfg.ConnectionString)
-	if err != nil {
-		ready <- fmt.Errorf("1C Connect failed: %w", err)
-		return
-	}
-
-	// Get справочники
-	spr, err := oleutil.GetProperty(c.v8.ToIDispatch(), "Справочники")
-	if err != nil {
-		c.cleanup()
-		ready <- fmt.Errorf("object property 'Справочники' not found: %w", err)
-		return
-	}
-
-	// Get ДополнительныеОтчетыИОбработки
-	sprOtch, err := oleutil.GetProperty(spr.ToIDispatch(), "ДополнительныеОтчетыИОбработки")
-	spr.Clear() // Clear spr now that we have sprOtch
-	if err != nil {
-		c.cleanup()
-		ready <- fmt.Errorf("object property 'ДополнительныеОтчетыИОбработки' not found: %w", err)
-		return
-	}
+	logger.Infof("COM connection %d initialized successfully", c.id)
+	ready <- nil
 
-	// Find обработка by name
-	extForm, err := oleutil.CallMethod(sprOtch.ToIDispatch(), "НайтиПоНаименованию", cfg.CommandExec, true)
-	sprOtch.Clear() // Clear sprOtch now that we have extForm
-	if err != nil {
-		c.cleanup()
-		ready <- fmt.Errorf("method 'НайтиПоНаименованию()' not found: %w", err)
-		return
+	// Process incoming commands
+	for {
+		select {
+		case fn := <-c.commands:
+			fn()
+		case <-c.quit:
+			logger.Debugf("COM connection %d worker shutting down", c.id)
+			c.session.Close()
+			c.session = nil
+			return
+		}
 	}
+}
 
-	// Check if empty
-	isEmpty, err := oleutil.CallMethod(extForm.ToIDispatch(), "Пустая")
-	if err != nil {
-		extForm.Clear()
-		c.cleanup()
-		ready <- fmt.Errorf("method 'Пустая()' not found: %w", err)
-		return
+// run executes fn on the worker goroutine and waits for the result.
+// When ctx is done or the command timeout fires before fn returns,
+// the call is abandoned and the connection is marked as tainted
+// if fn has already started. A call on a connection being closed
+// fails with ErrPoolClosed.
+func (c *COMConnection) run(ctx context.Context, fn func(s Session) (any, error)) (any, error) {
+	resultChan := make(chan Result, 1)
+	var state atomic.Int32
+
+	var timer *time.Timer
+	var timeout <-chan time.Time
+	if c.timeout > 0 {
+		timer = time.NewTimer(c.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	abandon := func() {
+		if !state.CompareAndSwap(callQueued, callAbandoned) {
+			c.mutex.Lock()
+			c.tainted = true
+			c.mutex.Unlock()
+		}
 	}
 
-	isEmptyRes, ok := isEmpty.Value().(bool)
-	isEmpty.Clear() // Clear isEmpty immediately after getting value
-	if !ok {
-		extForm.Clear()
-		c.cleanup()
-		ready <- fmt.Errorf("invalid result type from Пустая()")
-		return
-	}
-	if isEmptyRes {
-		extForm.Clear()
-		c.cleanup()
-		ready <- fmt.Errorf("не найдена внешняя обработка \"%s\"", cfg.CommandExec)
-		return
+	cmd := func() {
+		if !state.CompareAndSwap(callQueued, callStarted) {
+			return // abandoned before it started
+		}
+		c.callTimer = timer
+		val, err := fn(c.session)
+		c.callTimer = nil
+		resultChan <- Result{Value: val, Error: err}
 	}
 
-	// Get temporary filename
-	tempFileName, err := oleutil.CallMethod(c.v8.ToIDispatch(), "ПолучитьИмяВременногоФайла")
-	if err != nil {
-		extForm.Clear()
-		c.cleanup()
-		ready <- fmt.Errorf("method 'ПолучитьИмяВременногоФайла()' not found: %w", err)
-		return
+	select {
+	case c.commands <- cmd:
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 
-	// Get ХранилищеОбработки from extForm
-	obrStore, err := oleutil.GetProperty(extForm.ToIDispatch(), "ХранилищеОбработки")
-	if err != nil {
-		tempFileName.Clear()
-		extForm.Clear()
-		c.cleanup()
-		ready <- fmt.Errorf("object property 'ХранилищеОбработки' not found: %w", err)
-		return
+	select {
+	case result := <-resultChan:
+		if result.Error != nil {
+			if errors.Is(result.Error, ErrConnBroken) {
+				c.mutex.Lock()
+				c.broken = true
+				c.mutex.Unlock()
+			}
+			return nil, result.Error
+		}
+		return result.Value, nil
+	case <-timeout:
+		abandon()
+		return nil, ErrCommandTimeout
+	case <-ctx.Done():
+		abandon()
+		return nil, ctx.Err()
+	case <-c.quit:
+		select {
+		case result := <-resultChan:
+			return result.Value, result.Error
+		default:
+		}
+		abandon()
+		return nil, ErrPoolClosed
 	}
+}
 
-	// Get data from storage
-	data, err := oleutil.CallMethod(obrStore.ToIDispatch(), "Получить")
-	obrStore.Clear() // Clear obrStore now that we have data
-	if err != nil {
-		tempFileName.Clear()
-		extForm.Clear()
-		c.cleanup()
-		ready <- fmt.Errorf("method 'Получить()' not found: %w", err)
-		return
-	}
+// pauseTimeout stops the timeout of the running call while wait blocks
+// on the reader of a streamed result, so that CommandTimeout limits
+// the COM calls and not the reader. It is called by fn of run, on
+// the worker goroutine.
+func (c *COMConnection) pauseTimeout(wait func() error) error {
+	timer := c.callTimer
+	if timer == nil || !timer.Stop() {
+		// no limit, or it has run out and the call is abandoned
+		return wait()
+	}
+	defer timer.Reset(c.timeout)
+	return wait()
+}
 
-	// Write to temp file
-	_, err = oleutil.CallMethod(data.ToIDispatch(), "Записать", tempFileName.Value())
-	data.Clear() // Clear data after writing
-	if err != nil {
-		tempFileName.Clear()
-		extForm.Clear()
-		c.cleanup()
-		ready <- fmt.Errorf("method 'Записать()' not found: %w", err)
-		return
-	}
+// ExecuteCommand executes a command on this COM connection
+func (c *COMConnection) ExecuteCommand(command string, params string) (string, error) {
+	return c.ExecuteCommandContext(context.Background(), command, params)
+}
 
-	// Get ВнешниеОбработки
-	c.commandExecParent, err = oleutil.GetProperty(c.v8.ToIDispatch(), "ВнешниеОбработки")
-	if err != nil {
-		tempFileName.Clear()
-		extForm.Clear()
-		c.cleanup()
-		ready <- fmt.Errorf("object property 'ВнешниеОбработки' not found: %w", err)
-		return
-	}
-	// Keep commandExecParent alive for the connection lifetime
-
-	// DEBUG: Log before Создать()
-	logger.Debugf("Creating обработка from temp file: %v", tempFileName.Value())
-
-	// Call Создать on внешниеОбработки
-	c.commandExec, err = oleutil.CallMethod(c.commandExecParent.ToIDispatch(), "Создать", tempFileName.Value(), false)
-	
-	// NOW we can clear tempFileName and extForm - after Создать() is done
-	tempFileName.Clear()
-	extForm.Clear()
-	
+// ExecuteCommandContext executes a command on this COM connection,
+// abandoning the call when ctx is done
+func (c *COMConnection) ExecuteCommandContext(ctx context.Context, command string, params string) (string, error) {
+	val, err := c.run(ctx, func(s Session) (any, error) {
+		return s.ExecuteCommand(command, params)
+	})
 	if err != nil {
-		c.commandExecParent.Clear()
-		c.cleanup()
-		ready <- fmt.Errorf("method 'Создать()' not found: %w", err)
-		return
+		return "", &CommandError{ConnID: c.id, Command: command, Phase: PhaseExecute, Err: err}
 	}
 
-	logger.Infof("COM connection %d initialized successfully", c.id)
-	ready <- nil
-
-	// Process incoming commands
-	for {
-		select {
-		case fn := <-c.commands:
-			fn()
-		case <-c.quit:
-			logger.Debugf("COM connection %d worker shutting down", c.id)
-			
-			// Cleanup in reverse order
-			if c.commandExec != nil {
-				c.commandExec.Clear()
-				c.commandExec = nil
-			}
-			if c.commandExecParent != nil {
-				c.commandExecParent.Clear()
-				c.commandExecParent = nil
-			}
-			if c.v8 != nil {
-				c.v8.Clear()
-				c.v8 = nil
-			}
-			
-			return
-		}
+	str, ok := val.(string)
+	if !ok {
+		return "", &CommandError{ConnID: c.id, Command: command, Phase: PhaseResult,
+			Err: fmt.Errorf("result can not be converted to string")}
 	}
+	return str, nil
 }
 
+// Do runs fn on the worker goroutine with the connection session.
+// Objects fn gets from the session must not escape it and
+// must be released before it returns.
+func (c *COMConnection) Do(ctx context.Context, fn func(s Session) error) error {
+	_, err := c.run(ctx, func(s Session) (any, error) {
+		return nil, fn(s)
+	})
+	return err
+}
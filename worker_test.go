@@ -0,0 +1,141 @@
+package gocom1c
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCommandTimeoutQuarantine(t *testing.T) {
+	b := &FakeBackend{Delay: 200 * time.Millisecond}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, CommandTimeout: 20 * time.Millisecond})
+
+	_, err := pool.ExecuteCommand("Slow", "{}")
+	if !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("ExecuteCommand = %v, want ErrCommandTimeout", err)
+	}
+	if code := ErrorCode(err); code != CodeCommandTimeout {
+		t.Fatalf("ErrorCode = %s, want %s", code, CodeCommandTimeout)
+	}
+
+	stats := pool.Stats()
+	if stats.Quarantined != 1 || stats.TimedOut != 1 {
+		t.Fatalf("stats = %+v, want the connection quarantined", stats)
+	}
+	// a replacement is opened for MinPoolSize while the call hangs
+	waitFor(t, "replacement connection", func() bool { return b.Opened() == 2 })
+	waitFor(t, "quarantined connection closed", func() bool { return pool.Stats().Quarantined == 0 })
+}
+
+func TestCommandTimeoutDisabled(t *testing.T) {
+	b := &FakeBackend{Delay: 50 * time.Millisecond}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, CommandTimeout: -1})
+
+	if pool.cfg.CommandTimeout >= 0 {
+		t.Fatalf("CommandTimeout = %v, want a negative one kept", pool.cfg.CommandTimeout)
+	}
+	if _, err := pool.ExecuteCommand("Slow", "{}"); err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	if stats := pool.Stats(); stats.Quarantined != 0 || stats.TimedOut != 0 {
+		t.Fatalf("stats = %+v, want no timeouts", stats)
+	}
+}
+
+func TestCommandTimeoutExcludesReader(t *testing.T) {
+	content := bytes.Repeat([]byte("1C"), 1<<20)
+	b := &FakeBackend{
+		BinaryHandler: func(command string, params string) ([]byte, error) {
+			return content, nil
+		},
+	}
+	pool := newTestPool(t, b, Config{MinPoolSize: 1, CommandTimeout: 20 * time.Millisecond})
+
+	bin, err := pool.ExecuteBinary(context.Background(), "File", "{}")
+	if err != nil {
+		t.Fatalf("ExecuteBinary: %v", err)
+	}
+	defer bin.Close()
+
+	// a slow reader does not time the command out
+	time.Sleep(100 * time.Millisecond)
+	got, err := io.ReadAll(bin)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("read %d bytes, want %d", len(got), len(content))
+	}
+	if err := bin.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if stats := pool.Stats(); stats.TimedOut != 0 || stats.Idle != 1 {
+		t.Fatalf("stats = %+v, want the connection back idle", stats)
+	}
+}
+
+func TestMaxQuarantined(t *testing.T) {
+	hang := make(chan struct{})
+	b := &FakeBackend{
+		Handler: func(command string, params string) (string, error) {
+			if command == "Hang" {
+				<-hang
+			}
+			return fakeEcho(command, params)
+		},
+	}
+	pool := newTestPool(t, b, Config{
+		MinPoolSize:     1,
+		MaxPoolSize:     1,
+		MaxQuarantined:  1,
+		CommandTimeout:  20 * time.Millisecond,
+		WaitConnTimeout: 5 * time.Second,
+	})
+	var once sync.Once
+	release := func() { once.Do(func() { close(hang) }) }
+	t.Cleanup(release)
+	sessions := func() int { return b.Opened() - b.Closed() }
+
+	// the first hung connection is replaced
+	if _, err := pool.ExecuteCommand("Hang", "{}"); !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("ExecuteCommand = %v, want ErrCommandTimeout", err)
+	}
+	waitFor(t, "replacement connection", func() bool { return pool.Stats().Idle == 1 })
+
+	// the second one is not, it takes up the pool
+	if _, err := pool.ExecuteCommand("Hang", "{}"); !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("ExecuteCommand = %v, want ErrCommandTimeout", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if stats := pool.Stats(); stats.Quarantined != 2 || stats.Active != 0 || sessions() != 2 {
+		t.Fatalf("stats = %+v, sessions = %d, want 2 quarantined and no replacement", stats, sessions())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := pool.ExecuteCommandContext(ctx, "Ping", "{}"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ExecuteCommandContext = %v, want DeadlineExceeded", err)
+	}
+	if n := sessions(); n != 2 {
+		t.Fatalf("sessions = %d, want MaxPoolSize+MaxQuarantined", n)
+	}
+
+	// a waiter gets a connection once the hung calls return
+	done := make(chan error, 1)
+	go func() {
+		_, err := pool.ExecuteCommand("Ping", "{}")
+		done <- err
+	}()
+	waitFor(t, "waiter queued", func() bool { return pool.Stats().Waiting == 1 })
+	release()
+	if err := <-done; err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	waitFor(t, "quarantined connections closed", func() bool { return pool.Stats().Quarantined == 0 })
+	if n := sessions(); n != 1 {
+		t.Fatalf("sessions = %d, want 1", n)
+	}
+}